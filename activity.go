@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dayActivity is how much happened on one local day
+type dayActivity struct {
+	Sessions int
+	Messages int
+}
+
+// activity counts user and assistant messages by local day and hour
+type activity struct {
+	Days     map[string]*dayActivity // Keyed by YYYY-MM-DD
+	Hours    [24]int                 // Messages per hour of the day
+	Sessions int                     // Sessions with a message in the period
+	Messages int
+}
+
+// collectActivity counts the chat messages of items sent on or after since
+func collectActivity(items []listItem, since time.Time) activity {
+	a := activity{Days: make(map[string]*dayActivity)}
+	for _, item := range items {
+		active := false
+		seen := make(map[string]bool) // Days this session counts towards
+		for _, msg := range item.conv.Messages {
+			if (msg.Role != "user" && msg.Role != "assistant") || msg.Kind != "" {
+				continue
+			}
+			t, ok := parseTimestamp(msg.Ts)
+			if !ok || t.Before(since) {
+				continue
+			}
+			t = t.Local()
+			key := t.Format("2006-01-02")
+			day := a.Days[key]
+			if day == nil {
+				day = &dayActivity{}
+				a.Days[key] = day
+			}
+			day.Messages++
+			if !seen[key] {
+				seen[key] = true
+				day.Sessions++
+			}
+			a.Hours[t.Hour()]++
+			a.Messages++
+			active = true
+		}
+		if active {
+			a.Sessions++
+		}
+	}
+	return a
+}
+
+// heatLevel buckets n into 0 (nothing) to 4 (close to the busiest day)
+func heatLevel(n, busiest int) int {
+	if n <= 0 || busiest <= 0 {
+		return 0
+	}
+	return min(4, (n*4+busiest-1)/busiest)
+}
+
+// heatColors are the 256-colour greens for each level, like GitHub's
+var heatColors = [5]int{238, 22, 28, 34, 40}
+
+func heatCell(level int) string {
+	return fmt.Sprintf("\033[38;5;%dm■\033[0m ", heatColors[level])
+}
+
+// renderHeatmap draws a calendar of weeks (columns) by weekday (rows) from
+// the week containing since up to today, shaded by sessions or messages
+func renderHeatmap(a activity, since, today time.Time, metric string) []string {
+	value := func(d *dayActivity) int {
+		if d == nil {
+			return 0
+		}
+		if metric == "sessions" {
+			return d.Sessions
+		}
+		return d.Messages
+	}
+	busiest := 0
+	for _, d := range a.Days {
+		busiest = max(busiest, value(d))
+	}
+
+	// Weeks start on Monday
+	start := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.Local)
+	start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	weeks := max(1, int(end.Sub(start).Hours()/24)/7+1) // At least one, even if since is after today
+
+	// Month labels above the first week of each month, where they fit
+	months := []byte(strings.Repeat(" ", weeks*2+2))
+	free := 0 // First column not taken by the previous label
+	for w, lastMonth := 0, time.Month(0); w < weeks; w++ {
+		month := start.AddDate(0, 0, w*7).Month()
+		if month == lastMonth {
+			continue
+		}
+		lastMonth = month
+		label := month.String()[:3]
+		if pos := w * 2; pos >= free && pos+len(label) <= len(months) {
+			copy(months[pos:], label)
+			free = pos + len(label) + 1
+		}
+	}
+	lines := []string{"    " + strings.TrimRight(string(months), " ")}
+
+	labels := [7]string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	for wd := 0; wd < 7; wd++ {
+		var row strings.Builder
+		row.WriteString(fmt.Sprintf("%-3s ", labels[wd]))
+		for w := 0; w < weeks; w++ {
+			day := start.AddDate(0, 0, w*7+wd)
+			if day.After(end) {
+				break
+			}
+			if day.Before(since) {
+				row.WriteString("  ")
+				continue
+			}
+			row.WriteString(heatCell(heatLevel(value(a.Days[day.Format("2006-01-02")]), busiest)))
+		}
+		lines = append(lines, strings.TrimRight(row.String(), " "))
+	}
+
+	legend := "    Less "
+	for level := range heatColors {
+		legend += heatCell(level)
+	}
+	lines = append(lines, legend+"More ("+metric+" per day)")
+	return lines
+}
+
+// renderHours draws a bar chart of messages per hour of the day
+func renderHours(a activity) []string {
+	busiest := 0
+	for _, n := range a.Hours {
+		busiest = max(busiest, n)
+	}
+	const width = 40
+	var lines []string
+	for hour, n := range a.Hours {
+		bar := 0
+		if busiest > 0 {
+			bar = (n*width + busiest - 1) / busiest
+		}
+		lines = append(lines, fmt.Sprintf("%02d:00  \033[32m%-*s\033[0m %d", hour, width, strings.Repeat("█", bar), n))
+	}
+	return lines
+}
+
+// renderTimeline lists sessions and messages for each active week
+func renderTimeline(a activity) []string {
+	type week struct {
+		key      string
+		sessions int
+		messages int
+	}
+	byWeek := make(map[string]*week)
+	for key, d := range a.Days {
+		t, err := time.ParseInLocation("2006-01-02", key, time.Local)
+		if err != nil {
+			continue
+		}
+		year, w := t.ISOWeek()
+		wk := fmt.Sprintf("%d-W%02d", year, w)
+		if byWeek[wk] == nil {
+			byWeek[wk] = &week{key: wk}
+		}
+		byWeek[wk].sessions += d.Sessions
+		byWeek[wk].messages += d.Messages
+	}
+	weeks := make([]*week, 0, len(byWeek))
+	busiest := 0
+	for _, w := range byWeek {
+		weeks = append(weeks, w)
+		busiest = max(busiest, w.messages)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].key > weeks[j].key })
+
+	const width = 30
+	lines := []string{fmt.Sprintf("%-8s  %8s  %8s", "WEEK", "SESSIONS", "MESSAGES")}
+	for _, w := range weeks {
+		bar := (w.messages*width + busiest - 1) / busiest
+		lines = append(lines, fmt.Sprintf("%-8s  %8d  %8d  \033[32m%s\033[0m", w.key, w.sessions, w.messages, strings.Repeat("█", bar)))
+	}
+	return lines
+}
+
+// runActivity implements `ccs activity`: when and how much the agent was used
+func runActivity(args []string) error {
+	scan := defaultScanFlags()
+	scan.maxAgeDays = 0 // --since decides which files are relevant
+	today := time.Now()
+	since := time.Date(today.Year(), today.Month(), today.Day()-7*52+1, 0, 0, 0, 0, time.Local)
+	project, metric, query := "", "messages", ""
+	hours := false
+	for _, arg := range args {
+		switch {
+		case scan.parse(arg):
+		case strings.HasPrefix(arg, "--project="):
+			project = strings.TrimPrefix(arg, "--project=")
+		case strings.HasPrefix(arg, "--since="):
+			day, err := parseQueryDate(strings.TrimPrefix(arg, "--since="))
+			if err != nil {
+				return err
+			}
+			if day.After(today) {
+				return fmt.Errorf("--since=%s is in the future", day.Format("2006-01-02"))
+			}
+			since = day
+		case strings.HasPrefix(arg, "--by="):
+			metric = strings.TrimPrefix(arg, "--by=")
+			if metric != "messages" && metric != "sessions" {
+				return fmt.Errorf("invalid --by value %q (want messages or sessions)", metric)
+			}
+		case arg == "--hours":
+			hours = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		default:
+			query = arg
+		}
+	}
+
+	// Files last written before since hold nothing to count
+	scan.after = since
+	items, err := scan.load(searchOptions{})
+	if err != nil {
+		return err
+	}
+	if items, err = filterItems(items, query); err != nil {
+		return err
+	}
+	if project != "" {
+		var kept []listItem
+		for _, item := range items {
+			if containsFold(filepath.Base(item.conv.Cwd), project) {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+
+	a := collectActivity(items, since)
+	fmt.Printf("%d sessions, %d messages on %d days since %s\n\n", a.Sessions, a.Messages, len(a.Days), since.Format("2006-01-02"))
+	for _, line := range renderHeatmap(a, since, today, metric) {
+		fmt.Println(line)
+	}
+	if hours {
+		fmt.Println()
+		for _, line := range renderHours(a) {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+	for _, line := range renderTimeline(a) {
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// localTs formats a local time the way session files record timestamps
+func localTs(year int, month time.Month, day, hour int) string {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.Local).UTC().Format(time.RFC3339)
+}
+
+func TestCollectActivity(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	items := buildItems([]Conversation{
+		{SessionID: "s1", Messages: []Message{
+			{Role: "user", Text: "a", Ts: localTs(2024, 3, 4, 9)},
+			{Role: "assistant", Text: "b", Ts: localTs(2024, 3, 4, 9)},
+			{Role: "tool", Kind: kindToolUse, Text: "c", Ts: localTs(2024, 3, 4, 9), Tool: &ToolCall{}},
+			{Role: "user", Text: "d", Ts: localTs(2024, 3, 6, 22)},
+		}},
+		{SessionID: "s2", Messages: []Message{
+			{Role: "user", Text: "e", Ts: localTs(2024, 3, 4, 14)},
+			{Role: "user", Text: "old", Ts: localTs(2024, 1, 1, 10)},
+		}},
+	}, searchOptions{})
+	a := collectActivity(items, since)
+
+	if a.Sessions != 2 || a.Messages != 4 {
+		t.Errorf("totals = %d sessions, %d messages, want 2, 4", a.Sessions, a.Messages)
+	}
+	if d := a.Days["2024-03-04"]; d == nil || d.Sessions != 2 || d.Messages != 3 {
+		t.Errorf("2024-03-04 = %+v, want 2 sessions, 3 messages", d)
+	}
+	if a.Days["2024-01-01"] != nil {
+		t.Error("messages before since should be ignored")
+	}
+	if a.Hours[9] != 2 || a.Hours[14] != 1 || a.Hours[22] != 1 {
+		t.Errorf("hours = %v", a.Hours)
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct {
+		n, busiest, want int
+	}{
+		{0, 10, 0},
+		{1, 10, 1},
+		{5, 10, 2},
+		{10, 10, 4},
+		{3, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := heatLevel(tt.n, tt.busiest); got != tt.want {
+			t.Errorf("heatLevel(%d, %d) = %d, want %d", tt.n, tt.busiest, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local) // A Friday
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	items := buildItems([]Conversation{{SessionID: "s1", Messages: []Message{
+		{Role: "user", Text: "a", Ts: localTs(2024, 3, 4, 9)},
+		{Role: "assistant", Text: "b", Ts: localTs(2024, 3, 4, 9)},
+		{Role: "user", Text: "c", Ts: localTs(2024, 3, 6, 22)},
+	}}}, searchOptions{})
+	a := collectActivity(items, since)
+	lines := renderHeatmap(a, since, today, "messages")
+
+	if len(lines) != 9 {
+		t.Fatalf("expected month row, 7 weekdays and legend, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "    Feb") {
+		t.Errorf("month row = %q, want Feb first (week of Feb 26)", lines[0])
+	}
+	// Monday row: blank before since, then Mar 4 as the busiest day
+	mon := lines[1]
+	if !strings.HasPrefix(mon, "Mon   ") || !strings.Contains(mon, "\033[38;5;40m■") {
+		t.Errorf("Monday row = %q", mon)
+	}
+	if !strings.Contains(lines[8], "messages per day") {
+		t.Errorf("legend = %q", lines[8])
+	}
+}
+
+func TestRenderHeatmapFutureSince(t *testing.T) {
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	lines := renderHeatmap(activity{}, today.AddDate(0, 3, 0), today, "messages")
+	if len(lines) == 0 {
+		t.Error("a since after today should still draw a week, not panic")
+	}
+}
+
+func TestRenderTimeline(t *testing.T) {
+	items := buildItems([]Conversation{{SessionID: "s1", Messages: []Message{
+		{Role: "user", Text: "a", Ts: localTs(2024, 3, 4, 9)},
+		{Role: "user", Text: "old", Ts: localTs(2024, 1, 1, 10)},
+	}}}, searchOptions{})
+	a := collectActivity(items, time.Time{})
+	lines := renderTimeline(a)
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "2024-W10") || !strings.HasPrefix(lines[2], "2024-W01") {
+		t.Errorf("timeline = %q", lines)
+	}
+}
+
+func TestRunActivityRejectsBadFlags(t *testing.T) {
+	future := time.Now().AddDate(1, 0, 0).Format("2006-01-02")
+	for _, args := range [][]string{{"--since=yesterday"}, {"--since=" + future}, {"--by=tokens"}, {"--bogus"}} {
+		if err := runActivity(args); err == nil {
+			t.Errorf("runActivity(%q) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// agentFilePrefix names the transcript files of Task sub-agents. They sit
+// next to their parent session or in a <session>/subagents directory, and
+// every line carries the parent's sessionId.
+const agentFilePrefix = "agent-"
+
+// isAgentFile reports whether path is a sub-agent transcript
+func isAgentFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), agentFilePrefix)
+}
+
+// allMessages returns the conversation's messages followed by those of its
+// sub-agent threads
+func (conv Conversation) allMessages() []Message {
+	if len(conv.Agents) == 0 {
+		return conv.Messages
+	}
+	msgs := append([]Message(nil), conv.Messages...)
+	for _, agent := range conv.Agents {
+		msgs = append(msgs, agent.Messages...)
+	}
+	return msgs
+}
+
+// attachAgents moves sub-agent entries onto their parent conversations.
+// Parents are copied rather than modified, so cached entries never hold
+// agents; when idx is non-nil their search text is rebuilt to include the
+// agents' messages. Agents whose parent isn't in entries are dropped.
+func attachAgents(idx *searchIndex, entries []*indexEntry) []*indexEntry {
+	agents := make(map[string][]Conversation)
+	var parents []*indexEntry
+	for _, entry := range entries {
+		if isAgentFile(entry.Conv.FilePath) {
+			agents[entry.Conv.ParentID] = append(agents[entry.Conv.ParentID], *entry.Conv)
+		} else {
+			parents = append(parents, entry)
+		}
+	}
+	delete(agents, "") // No sessionId to link them by
+	if len(agents) == 0 {
+		return parents
+	}
+
+	for i, entry := range parents {
+		threads := agents[entry.Conv.SessionID]
+		if len(threads) == 0 {
+			continue
+		}
+		sort.Slice(threads, func(a, b int) bool {
+			return threads[a].FirstTimestamp < threads[b].FirstTimestamp
+		})
+
+		conv := *entry.Conv
+		conv.Agents = threads
+		attached := *entry
+		attached.Conv = &conv
+		if idx != nil {
+			attached.SearchText = buildSearchText(conv, idx.Search)
+		}
+		parents[i] = &attached
+	}
+	return parents
+}
+
+// renderAgents renders the sub-agent threads for the preview: a header per
+// thread with its hit count, followed by its messages when expanded
+func (m model) renderAgents(agents []Conversation) []string {
+	var lines []string
+	for _, agent := range agents {
+		msgs := agent.Messages
+		if !m.showThinking {
+			msgs = withoutThinking(msgs)
+		}
+
+		hits := 0
+		if !m.match.empty() {
+			for _, msg := range msgs {
+				if m.match.matchMessage(msg) {
+					hits++
+				}
+			}
+		}
+
+		summary := fmt.Sprintf("%d msgs", agent.chatMessageCount())
+		if hits > 0 {
+			summary += fmt.Sprintf(", %d hits", hits)
+		}
+		topic := truncate(strings.ReplaceAll(m.previewText(getTopic(agent)), "\n", " "), 60)
+		if hits > 0 {
+			lines = append(lines, fmt.Sprintf("\033[1;36m>>> Sub-agent: %s (%s)\033[0m", topic, summary)) // Bold cyan
+		} else {
+			lines = append(lines, fmt.Sprintf("\033[36m    Sub-agent: %s (%s)\033[0m", topic, summary)) // Cyan
+		}
+
+		if !m.expandAgents {
+			if hits > 0 {
+				lines = append(lines, "\033[90m    Ctrl+O to expand\033[0m")
+			}
+			lines = append(lines, "")
+			continue
+		}
+		for _, line := range m.renderMessages(msgs) {
+			if line == "" {
+				lines = append(lines, line)
+			} else {
+				lines = append(lines, "  \033[36m│\033[0m "+line)
+			}
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
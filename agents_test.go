@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// writeAgentSession writes a parent session with one sub-agent next to it
+// and one in the newer <session>/subagents layout
+func writeAgentSession(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"session-1.jsonl": `{"type":"user","cwd":"/src/app","sessionId":"session-1","message":{"content":"why is CI slow?"},"timestamp":"2024-01-15T10:00:00Z"}
+{"type":"assistant","sessionId":"session-1","message":{"content":"Found it: the cache was cold."},"timestamp":"2024-01-15T10:10:00Z"}
+`,
+		"agent-a1.jsonl": `{"type":"user","cwd":"/src/app","sessionId":"session-1","isSidechain":true,"message":{"content":"Explore the CI config"},"timestamp":"2024-01-15T10:01:00Z"}
+{"type":"assistant","sessionId":"session-1","isSidechain":true,"message":{"content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":".github/workflows/ci.yaml"}}]},"timestamp":"2024-01-15T10:02:00Z"}
+{"type":"assistant","sessionId":"session-1","isSidechain":true,"message":{"content":"The gradle cache key changes on every run."},"timestamp":"2024-01-15T10:03:00Z"}
+`,
+		"session-1/subagents/agent-b2.jsonl": `{"type":"user","cwd":"/src/app","sessionId":"session-1","isSidechain":true,"message":{"content":"Run the test suite"},"timestamp":"2024-01-15T10:04:00Z"}
+{"type":"assistant","sessionId":"session-1","isSidechain":true,"message":{"content":"All 312 tests pass."},"timestamp":"2024-01-15T10:05:00Z"}
+`,
+		"agent-orphan.jsonl": `{"type":"user","cwd":"/src/app","sessionId":"gone","isSidechain":true,"message":{"content":"orphaned work"},"timestamp":"2024-01-15T10:00:00Z"}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadItemsAttachesAgents(t *testing.T) {
+	dir := setupIndexTest(t)
+	writeAgentSession(t, dir)
+
+	// Twice: once parsing, once from the index
+	for run := 0; run < 2; run++ {
+		items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+		if err != nil {
+			t.Fatalf("loadItems failed: %v", err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("run %d: expected only the parent session, got %d items", run, len(items))
+		}
+		conv := items[0].conv
+		if len(conv.Agents) != 2 {
+			t.Fatalf("run %d: expected 2 sub-agents, got %d", run, len(conv.Agents))
+		}
+		if conv.Agents[0].SessionID != "agent-a1" || conv.Agents[1].SessionID != "agent-b2" {
+			t.Errorf("agents = %s, %s, want agent-a1, agent-b2 in order", conv.Agents[0].SessionID, conv.Agents[1].SessionID)
+		}
+		if !strings.Contains(items[0].searchText, "gradle cache key") {
+			t.Errorf("run %d: search text should include sub-agent messages", run)
+		}
+		if strings.Contains(items[0].searchText, "orphaned") {
+			t.Errorf("run %d: orphaned agents should be dropped", run)
+		}
+	}
+
+	// Cached entries stay agent-free
+	idx := loadIndex()
+	if entry := idx.Entries[filepath.Join(dir, "session-1.jsonl")]; entry == nil || len(entry.Conv.Agents) != 0 {
+		t.Error("index should cache the parent without its agents")
+	}
+}
+
+func TestAgentQueries(t *testing.T) {
+	dir := setupIndexTest(t)
+	writeAgentSession(t, dir)
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	item := items[0]
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"gradle", true},
+		{"tool:Read", true},
+		{"file:ci.yaml", true},
+		{"role:assistant 312 tests", true},
+		{"role:user gradle", false},
+	}
+	for _, tt := range tests {
+		mt, err := newMatcher(tt.query, modeExact)
+		if err != nil {
+			t.Fatalf("newMatcher(%q) failed: %v", tt.query, err)
+		}
+		if _, ok := mt.matchItem(item); ok != tt.want {
+			t.Errorf("query %q matched = %v, want %v", tt.query, ok, tt.want)
+		}
+	}
+}
+
+func TestRenderPreviewAgents(t *testing.T) {
+	dir := setupIndexTest(t)
+	writeAgentSession(t, dir)
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	m := initialModel(items, "gradle", nil)
+
+	preview := m.renderPreview(items[0], 100)
+	if !strings.Contains(preview, "Sub-agent: Explore the CI config (2 msgs, 1 hits)") {
+		t.Errorf("preview should flag the sub-agent hit, got %q", preview)
+	}
+	if strings.Contains(preview, "cache key changes") {
+		t.Error("sub-agent threads should be collapsed by default")
+	}
+
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = newM.(model)
+	preview = m.renderPreview(items[0], 100)
+	if !strings.Contains(preview, "cache key changes") {
+		t.Errorf("ctrl+o should expand sub-agent threads, got %q", preview)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// getDataDir returns the directory ccs keeps its own data in: the trash,
+// the archive and annotations
+// Declared as a variable so it can be overridden in tests
+var getDataDir = func() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ccs")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "ccs")
+}
+
+func annotationsPath() string {
+	return filepath.Join(getDataDir(), "annotations.json")
+}
+
+// annotation is what the user has recorded about a session. It is kept in
+// ccs's own store, never in Claude Code's files.
+type annotation struct {
+	Starred bool     `json:"starred,omitempty"`
+	Tags    []string `json:"tags,omitempty"` // Lower case, sorted
+	Note    string   `json:"note,omitempty"`
+}
+
+func (a annotation) empty() bool {
+	return !a.Starred && len(a.Tags) == 0 && a.Note == ""
+}
+
+func (a annotation) hasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// label is the star and tags shown before the topic in the list
+func (a annotation) label() string {
+	var parts []string
+	if a.Starred {
+		parts = append(parts, "★")
+	}
+	for _, tag := range a.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseTags splits a space- or comma-separated list of tags, dropping
+// duplicates and any leading #
+func parseTags(s string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		tag := strings.ToLower(strings.TrimLeft(field, "#"))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// annotationStore maps session IDs to their annotations
+type annotationStore map[string]annotation
+
+// loadAnnotations reads the store. A missing file is an empty store; an
+// unreadable or malformed one is an error.
+func loadAnnotations() (annotationStore, error) {
+	store := make(annotationStore)
+	data, err := os.ReadFile(annotationsPath())
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", annotationsPath(), err)
+	}
+	return store, nil
+}
+
+// save writes the store atomically (temp file + rename)
+func (s annotationStore) save() error {
+	dir := getDataDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "annotations-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), annotationsPath())
+}
+
+// updateAnnotations applies edit to the annotations of the given sessions
+// and saves the store, returning the new annotations. The store is re-read
+// first so edits made by another ccs in the meantime aren't lost.
+func updateAnnotations(sessions []string, edit func(*annotation)) (annotationStore, error) {
+	store, err := loadAnnotations()
+	if err != nil {
+		return nil, err
+	}
+	updated := make(annotationStore, len(sessions))
+	for _, id := range sessions {
+		a := store[id]
+		edit(&a)
+		if a.empty() {
+			delete(store, id)
+		} else {
+			store[id] = a
+		}
+		updated[id] = a
+	}
+	return updated, store.save()
+}
+
+// annotate attaches the stored annotations to the items
+func annotate(items []listItem, store annotationStore) {
+	for i := range items {
+		items[i].conv.Annotation = store[items[i].conv.SessionID]
+	}
+}
+
+// annotationLine is the preview header line showing an annotation
+func annotationLine(a annotation) string {
+	var parts []string
+	if a.Starred {
+		parts = append(parts, "\033[1;33m★ Starred\033[0m")
+	}
+	if len(a.Tags) > 0 {
+		parts = append(parts, "\033[1;33mTags:\033[0m \033[35m"+strings.Join(a.Tags, ", ")+"\033[0m")
+	}
+	if a.Note != "" {
+		parts = append(parts, "\033[1;33mNote:\033[0m "+a.Note)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// editField is what the annotation input in the TUI is editing
+type editField int
+
+const (
+	editNone editField = iota
+	editTags
+	editNote
+)
+
+// sessionIDs returns the session IDs of items
+func sessionIDs(items []listItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.conv.SessionID
+	}
+	return ids
+}
+
+// commonTags returns the tags every item has
+func commonTags(items []listItem) []string {
+	if len(items) == 0 {
+		return nil
+	}
+	var common []string
+	for _, tag := range items[0].conv.Annotation.Tags {
+		shared := true
+		for _, item := range items[1:] {
+			shared = shared && item.conv.Annotation.hasTag(tag)
+		}
+		if shared {
+			common = append(common, tag)
+		}
+	}
+	return common
+}
+
+// applyAnnotations saves an edit to the items' annotations and shows the
+// result in the list
+func (m *model) applyAnnotations(items []listItem, edit func(*annotation)) {
+	updated, err := updateAnnotations(sessionIDs(items), edit)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Could not save: %v", err)
+		return
+	}
+	for _, list := range [][]listItem{m.items, m.filtered} {
+		for i := range list {
+			if a, ok := updated[list[i].conv.SessionID]; ok {
+				list[i].conv.Annotation = a
+			}
+		}
+	}
+	// Tags and stars can change what tag: and is:starred match
+	m.updateFilter()
+}
+
+// toggleStar stars the marked conversations, else the selected one, or
+// unstars them if they're all starred
+func (m *model) toggleStar() {
+	targets := m.actionTargets()
+	if len(targets) == 0 {
+		return
+	}
+	star := false
+	for _, item := range targets {
+		star = star || !item.conv.Annotation.Starred
+	}
+	m.applyAnnotations(targets, func(a *annotation) { a.Starred = star })
+}
+
+// startEdit swaps the search line for an input editing the tags of the
+// marked conversations, else the selected one, or the selected one's note
+func (m *model) startEdit(field editField) {
+	targets := m.actionTargets()
+	if len(targets) == 0 {
+		return
+	}
+	m.editing = field
+	m.editInput = textinput.New()
+	m.editInput.Prompt = ""
+	m.editInput.CharLimit = 500
+	m.editInput.Width = 80
+	switch field {
+	case editTags:
+		m.editInput.Placeholder = "tags, separated by spaces"
+		// With several conversations, only the tags they share are shown;
+		// removing one removes it from all and adding one adds it to all
+		m.editInput.SetValue(strings.Join(commonTags(targets), " "))
+	case editNote:
+		m.editInput.Placeholder = "a short note"
+		m.editInput.SetValue(m.filtered[m.cursor].conv.Annotation.Note)
+	}
+	m.editInput.CursorEnd()
+	m.editInput.Focus()
+}
+
+// finishEdit saves the edit input
+func (m *model) finishEdit() {
+	field, value := m.editing, strings.TrimSpace(m.editInput.Value())
+	m.editing = editNone
+	switch field {
+	case editTags:
+		targets := m.actionTargets()
+		before, after := commonTags(targets), parseTags(value)
+		m.applyAnnotations(targets, func(a *annotation) {
+			var tags []string
+			for _, tag := range a.Tags {
+				if !slices.Contains(before, tag) || slices.Contains(after, tag) {
+					tags = append(tags, tag)
+				}
+			}
+			a.Tags = parseTags(strings.Join(append(tags, after...), " "))
+		})
+	case editNote:
+		m.applyAnnotations(m.filtered[m.cursor:m.cursor+1], func(a *annotation) { a.Note = value })
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"incident design", []string{"design", "incident"}},
+		{"#Incident, incident,,onboarding", []string{"incident", "onboarding"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := parseTags(tt.in); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parseTags(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateAnnotations(t *testing.T) {
+	setupIndexTest(t)
+
+	if _, err := updateAnnotations([]string{"s1", "s2"}, func(a *annotation) { a.Starred = true }); err != nil {
+		t.Fatal(err)
+	}
+	updateAnnotations([]string{"s2"}, func(a *annotation) { a.Note = "root cause" })
+	store, err := loadAnnotations()
+	if err != nil || !store["s1"].Starred || store["s2"].Note != "root cause" || !store["s2"].Starred {
+		t.Fatalf("store = %+v, %v", store, err)
+	}
+
+	// An annotation with nothing left in it leaves the store
+	updateAnnotations([]string{"s1"}, func(a *annotation) { a.Starred = false })
+	if store, _ := loadAnnotations(); len(store) != 1 {
+		t.Errorf("store = %+v", store)
+	}
+
+	os.WriteFile(annotationsPath(), []byte("{not json"), 0644)
+	if _, err := updateAnnotations([]string{"s1"}, func(a *annotation) { a.Starred = true }); err == nil {
+		t.Error("a malformed store should be an error, not overwritten")
+	}
+}
+
+func TestAnnotationQueries(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "s1", LastTimestamp: "2024-01-20T10:00:00Z", Messages: []Message{{Role: "user", Text: "fix the login page"}}},
+		{SessionID: "s2", LastTimestamp: "2024-01-19T10:00:00Z", Messages: []Message{{Role: "user", Text: "login bug"}}},
+		{SessionID: "s3", LastTimestamp: "2024-01-18T10:00:00Z", Messages: []Message{{Role: "user", Text: "deploy"}}},
+	}, searchOptions{})
+	annotate(items, annotationStore{
+		"s1": {Starred: true, Tags: []string{"incident"}},
+		"s2": {Tags: []string{"design", "incident"}, Note: "Rate limiter plan"},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tag:incident", []string{"s1", "s2"}},
+		{"tag:#design", []string{"s2"}},
+		{"is:starred", []string{"s1"}},
+		{"note:limiter", []string{"s2"}},
+		{"-tag:incident", []string{"s3"}},
+	}
+	for _, tt := range tests {
+		got, err := filterItems(items, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(sessionIDs(got)) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, sessionIDs(got), tt.want)
+		}
+	}
+}
+
+func TestAnnotateKeys(t *testing.T) {
+	setupIndexTest(t)
+	items := buildItems([]Conversation{
+		{SessionID: "s1", LastTimestamp: "2024-01-20T10:00:00Z", Messages: []Message{{Role: "user", Text: "fix the login page"}}},
+		{SessionID: "s2", LastTimestamp: "2024-01-19T10:00:00Z", Messages: []Message{{Role: "user", Text: "login bug"}}},
+		{SessionID: "s3", LastTimestamp: "2024-01-18T10:00:00Z", Messages: []Message{{Role: "user", Text: "deploy"}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+	m.width, m.height = 120, 40
+	key := func(msg tea.KeyMsg) {
+		t.Helper()
+		result, _ := m.Update(msg)
+		m = result.(model)
+	}
+	typeText := func(s string) {
+		t.Helper()
+		key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyCtrlF})
+	if m.items[0].conv.Annotation.Starred {
+		t.Error("Ctrl+F is left to the search field and shouldn't star")
+	}
+	key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}, Alt: true})
+	if !m.items[0].conv.Annotation.Starred {
+		t.Error("Alt+S should star the selected conversation")
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyCtrlL})
+	typeText("incident design")
+	if m.textInput.Value() != "" {
+		t.Error("typing while editing tags should not go to the search box")
+	}
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.editing != editNone || fmt.Sprint(m.filtered[0].conv.Annotation.Tags) != "[design incident]" {
+		t.Fatalf("tags = %v, error %q", m.filtered[0].conv.Annotation.Tags, m.errorMsg)
+	}
+	if store, _ := loadAnnotations(); !store["s1"].Starred || len(store["s1"].Tags) != 2 {
+		t.Errorf("store = %+v", store)
+	}
+
+	key(tea.KeyMsg{Type: tea.KeyCtrlX})
+	typeText("see postmortem")
+	key(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.editing != editNone || m.filtered[0].conv.Annotation.Note != "" || m.quitting {
+		t.Error("Esc should cancel the edit without quitting")
+	}
+
+	// Tagging marked rows adds and removes tags without touching the others
+	m.markAll()
+	key(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if m.editInput.Value() != "" {
+		t.Errorf("only tags shared by every marked row should be shown, got %q", m.editInput.Value())
+	}
+	typeText("onboarding")
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	for _, item := range m.items {
+		if !item.conv.Annotation.hasTag("onboarding") {
+			t.Errorf("%s should be tagged onboarding", item.conv.SessionID)
+		}
+	}
+	if fmt.Sprint(m.items[0].conv.Annotation.Tags) != "[design incident onboarding]" {
+		t.Errorf("s1 should keep its own tags, got %v", m.items[0].conv.Annotation.Tags)
+	}
+	key(tea.KeyMsg{Type: tea.KeyCtrlL})
+	key(tea.KeyMsg{Type: tea.KeyCtrlU}) // Clears the input
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+	if fmt.Sprint(m.items[0].conv.Annotation.Tags) != "[design incident]" || len(m.items[1].conv.Annotation.Tags) != 0 {
+		t.Errorf("removing a shared tag should remove only it: %v, %v", m.items[0].conv.Annotation.Tags, m.items[1].conv.Annotation.Tags)
+	}
+
+	m.textInput.SetValue("tag:incident")
+	m.updateFilter()
+	if len(m.filtered) != 1 || m.filtered[0].conv.SessionID != "s1" {
+		t.Errorf("tag:incident = %v", sessionIDs(m.filtered))
+	}
+}
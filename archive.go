@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// getArchiveDir returns the directory archived conversations are kept in,
+// laid out like the projects directory with each file gzip-compressed
+// Declared as a variable so it can be overridden in tests
+var getArchiveDir = func() string {
+	return filepath.Join(getDataDir(), "archive")
+}
+
+// archiveExt is the extension of a compressed conversation file
+const archiveExt = ".jsonl.gz"
+
+// isArchived reports whether path is a compressed conversation file
+func isArchived(path string) bool {
+	return strings.HasSuffix(path, archiveExt)
+}
+
+// sessionFileID returns the session ID a conversation file is named after
+func sessionFileID(path string) string {
+	name := filepath.Base(path)
+	if isArchived(name) {
+		return strings.TrimSuffix(name, archiveExt)
+	}
+	return strings.TrimSuffix(name, ".jsonl")
+}
+
+// archivePath maps a file in the projects directory to its archive
+func archivePath(path string) (string, error) {
+	rel, err := filepath.Rel(getProjectsDir(), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is not in %s", path, getProjectsDir())
+	}
+	return filepath.Join(getArchiveDir(), rel+".gz"), nil
+}
+
+// originalPath maps an archive back to where it was in the projects
+// directory
+func originalPath(archived string) (string, error) {
+	rel, err := filepath.Rel(getArchiveDir(), archived)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is not in %s", archived, getArchiveDir())
+	}
+	return filepath.Join(getProjectsDir(), strings.TrimSuffix(rel, ".gz")), nil
+}
+
+// parseArchivedFile parses a compressed conversation file. Archives don't
+// change, so unlike live files they are never resumed.
+func parseArchivedFile(path string) (*Conversation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	conv := &Conversation{SessionID: sessionFileID(path), FilePath: path}
+	reader := bufio.NewReaderSize(gz, 1024*1024)
+	for {
+		lineBytes, err := reader.ReadBytes('\n')
+		if len(lineBytes) > 0 {
+			conv.parseLine(lineBytes)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !conv.finish() {
+		return nil, nil
+	}
+	return conv, nil
+}
+
+// convertFile writes src to dst through convert (compressing or
+// decompressing it), keeps its modification time and removes src. dst is
+// written under a temporary name so a failure leaves nothing half-written.
+func convertFile(src, dst string, convert func(w io.Writer, r io.Reader) error) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".ccs-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if err := convert(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Age filters go by modification time, so archiving mustn't reset it
+	if err := os.Chtimes(tmp.Name(), time.Now(), info.ModTime()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+func compress(w io.Writer, r io.Reader) error {
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, r); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func decompress(w io.Writer, r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	_, err = io.Copy(w, gz)
+	return err
+}
+
+// conversationPaths returns the paths of a conversation's session file and
+// its sub-agent transcripts
+func conversationPaths(conv Conversation) []string {
+	paths := []string{conv.FilePath}
+	for _, agent := range conv.Agents {
+		paths = append(paths, agent.FilePath)
+	}
+	return paths
+}
+
+// archiveConversation compresses a conversation's files into the archive
+func archiveConversation(conv Conversation) error {
+	var done []string
+	for i, path := range conversationPaths(conv) {
+		dst, err := archivePath(path)
+		if err == nil {
+			err = convertFile(path, dst, compress)
+		}
+		if err != nil {
+			if os.IsNotExist(err) && i > 0 {
+				continue // A sub-agent file already gone is no loss
+			}
+			// Put back what was already archived so nothing is half-archived
+			for _, archived := range done {
+				if original, err := originalPath(archived); err == nil {
+					convertFile(archived, original, decompress)
+				}
+			}
+			return err
+		}
+		done = append(done, dst)
+	}
+	return nil
+}
+
+// unarchiveConversation decompresses an archived conversation's files back
+// into the projects directory, so Claude Code can resume it
+func unarchiveConversation(conv Conversation) error {
+	for _, path := range conversationPaths(conv) {
+		if !isArchived(path) {
+			continue
+		}
+		original, err := originalPath(path)
+		if err != nil {
+			return err
+		}
+		if err := convertFile(path, original, decompress); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// runArchive implements `ccs archive`: compressing old sessions into the
+// archive, where they stay searchable
+func runArchive(args []string) error {
+	scan := defaultScanFlags()
+	scan.maxAgeDays, scan.maxSizeMB = 0, 0 // --older-than decides
+	var rules pruneRules
+	dryRun := false
+	for _, arg := range args {
+		switch {
+		case scan.parse(arg):
+		case arg == "--dry-run":
+			dryRun = true
+		case strings.HasPrefix(arg, "--older-than="):
+			age, err := parseAge(strings.TrimPrefix(arg, "--older-than="))
+			if err != nil {
+				return err
+			}
+			rules.olderThan = age
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		case rules.query != "":
+			return fmt.Errorf("expected one query, got %q and %q", rules.query, arg)
+		default:
+			rules.query = arg
+		}
+	}
+	if rules.olderThan == 0 {
+		return fmt.Errorf("usage: ccs archive --older-than=AGE [--dry-run] [query]")
+	}
+
+	items, err := scan.load(searchOptions{})
+	if err != nil {
+		return err
+	}
+	candidates, err := pruneCandidates(items, rules, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var before, after int64
+	archived, failed := 0, 0
+	for _, item := range candidates {
+		if isArchived(item.conv.FilePath) {
+			continue
+		}
+		paths, size := sessionFiles(item.conv)
+		if dryRun {
+			for _, path := range paths {
+				fmt.Println(path)
+			}
+		} else {
+			if err := archiveConversation(item.conv); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", item.conv.SessionID, err)
+				failed++
+				continue
+			}
+			for _, path := range paths {
+				if dst, err := archivePath(path); err == nil {
+					if info, err := os.Stat(dst); err == nil {
+						after += info.Size()
+					}
+				}
+			}
+		}
+		before += size
+		archived++
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould archive %d sessions (%s)\n", archived, formatSize(before))
+		return nil
+	}
+	fmt.Printf("Archived %d sessions to %s (%s compressed to %s)\n", archived, getArchiveDir(), formatSize(before), formatSize(after))
+	if failed > 0 {
+		return fmt.Errorf("%d sessions could not be archived", failed)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveAndRestore(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(filepath.Join(dir, "sess-1", "subagents"), 0755)
+	session := filepath.Join(dir, "sess-1.jsonl")
+	agent := filepath.Join(dir, "sess-1", "subagents", "agent-a1.jsonl")
+	sessionData := `{"type":"user","sessionId":"sess-1","cwd":"/src/api","message":{"content":"migrate the billing tables"},"timestamp":"2020-01-01T00:00:00Z"}` + "\n"
+	agentData := `{"type":"user","sessionId":"sess-1","cwd":"/src/api","message":{"content":"find the schema"},"timestamp":"2020-01-01T00:01:00Z"}` + "\n"
+	os.WriteFile(session, []byte(sessionData), 0644)
+	os.WriteFile(agent, []byte(agentData), 0644)
+	mtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(session, mtime, mtime)
+
+	if err := runArchive(nil); err == nil {
+		t.Error("archive without --older-than should refuse")
+	}
+	if err := runArchive([]string{"--older-than=30d", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(session); err != nil {
+		t.Fatal("--dry-run should leave the files alone")
+	}
+
+	if err := runArchive([]string{"--older-than=30d"}); err != nil {
+		t.Fatal(err)
+	}
+	archived := filepath.Join(getArchiveDir(), "-src-api", "sess-1.jsonl.gz")
+	info, err := os.Stat(archived)
+	if err != nil {
+		t.Fatalf("session not archived: %v", err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("archive mtime = %v, want the session's %v", info.ModTime(), mtime)
+	}
+	if _, err := os.Stat(session); !os.IsNotExist(err) {
+		t.Error("the archived session file should be removed")
+	}
+
+	// Archives are read like live files, but only once the age filter allows
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("loadItems = %+v, %v", items, err)
+	}
+	conv := items[0].conv
+	if conv.SessionID != "sess-1" || conv.FilePath != archived || len(conv.Agents) != 1 || !strings.Contains(items[0].searchText, "billing") {
+		t.Errorf("archived conversation = %+v", conv)
+	}
+	if items, _ := loadItems(time.Now().AddDate(0, 0, -60), 0, false, searchOptions{}); len(items) != 0 {
+		t.Error("an old archived session should be hidden by --max-age")
+	}
+	if path, err := findSessionFile("sess"); err != nil || path != archived {
+		t.Errorf("findSessionFile = %q, %v", path, err)
+	}
+
+	if err := unarchiveConversation(conv); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{session: sessionData, agent: agentData} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s restored as %q, %v", path, data, err)
+		}
+	}
+	if _, err := os.Stat(archived); !os.IsNotExist(err) {
+		t.Error("a restored session should leave the archive")
+	}
+}
+
+func TestArchivePaths(t *testing.T) {
+	setupIndexTest(t)
+	path := filepath.Join(getProjectsDir(), "-src-api", "sess-1.jsonl")
+	archived, err := archivePath(path)
+	if err != nil || archived != filepath.Join(getArchiveDir(), "-src-api", "sess-1.jsonl.gz") {
+		t.Fatalf("archivePath = %q, %v", archived, err)
+	}
+	if original, err := originalPath(archived); err != nil || original != path {
+		t.Errorf("originalPath = %q, %v", original, err)
+	}
+	if _, err := archivePath("/elsewhere/sess-1.jsonl"); err == nil {
+		t.Error("a file outside the projects directory can't be archived")
+	}
+	if sessionFileID(archived) != "sess-1" || sessionFileID(path) != "sess-1" {
+		t.Error("the session ID should not include the extension")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+// lineLink records where one line of a session file hangs in the message
+// tree. Rewinding or editing a prompt starts a new branch from an earlier
+// line, so a file can hold several branches.
+type lineLink struct {
+	UUID   string
+	Parent string
+}
+
+// branches splits the conversation into its branches, each given as indices
+// into Messages in file order. The active branch (ending at the most
+// recently written line) comes first, then abandoned ones, newest first.
+// Branches that add no messages of their own are left out, and sessions
+// recorded without uuids are a single branch.
+func (conv Conversation) branches() [][]int {
+	all := make([]int, len(conv.Messages))
+	for i := range all {
+		all[i] = i
+	}
+	if len(conv.Links) == 0 {
+		return [][]int{all}
+	}
+
+	parents := make(map[string]string, len(conv.Links))
+	for _, link := range conv.Links {
+		parents[link.UUID] = link.Parent
+	}
+
+	var result [][]int
+	seen := make(map[string]bool) // Lines on branches already returned
+	for _, leaf := range conv.branchLeaves() {
+		path := make(map[string]bool)
+		for id := leaf; id != "" && !path[id]; id = parents[id] {
+			path[id] = true
+		}
+
+		var indices []int
+		own := false
+		for i, msg := range conv.Messages {
+			if msg.UUID == "" || path[msg.UUID] {
+				indices = append(indices, i)
+				own = own || (msg.UUID != "" && !seen[msg.UUID])
+			}
+		}
+		if own || len(result) == 0 {
+			result = append(result, indices)
+		}
+		for id := range path {
+			seen[id] = true
+		}
+	}
+	return result
+}
+
+// branchLeaves returns the lines nothing else follows, last written first
+func (conv Conversation) branchLeaves() []string {
+	hasChild := make(map[string]bool, len(conv.Links))
+	for _, link := range conv.Links {
+		if link.Parent != "" {
+			hasChild[link.Parent] = true
+		}
+	}
+	var leaves []string
+	for i := len(conv.Links) - 1; i >= 0; i-- {
+		if id := conv.Links[i].UUID; !hasChild[id] {
+			leaves = append(leaves, id)
+		}
+	}
+	return leaves
+}
+
+// selectedBranch returns which branch of conv the preview shows
+func (m model) selectedBranch(conv Conversation, count int) int {
+	if conv.SessionID != m.branchSession || count == 0 {
+		return 0
+	}
+	return m.branch % count
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// forkedSession has its second prompt edited: u2 was abandoned for u3
+var forkedSession = []string{
+	`{"type":"user","uuid":"u1","parentUuid":null,"cwd":"/src/app","message":{"content":"add a retry to the client"},"timestamp":"2024-01-15T10:00:00Z"}`,
+	`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"content":"Added exponential backoff."},"timestamp":"2024-01-15T10:01:00Z"}`,
+	`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"content":"use a circuit breaker instead"},"timestamp":"2024-01-15T10:02:00Z"}`,
+	`{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"content":"Switched to a breaker."},"timestamp":"2024-01-15T10:03:00Z"}`,
+	`{"type":"system","uuid":"s1","parentUuid":"a1","content":"rewind"}`,
+	`{"type":"user","uuid":"u3","parentUuid":"a1","message":{"content":"cap retries at five"},"timestamp":"2024-01-15T10:04:00Z"}`,
+	`{"type":"assistant","uuid":"a3","parentUuid":"u3","message":{"content":"Capped at five attempts."},"timestamp":"2024-01-15T10:05:00Z"}`,
+}
+
+func TestBranches(t *testing.T) {
+	conv := parseJSONLFixture(t, forkedSession...)
+
+	branches := conv.branches()
+	if len(branches) != 2 || conv.BranchCount != 2 {
+		t.Fatalf("expected 2 branches, got %d (BranchCount %d)", len(branches), conv.BranchCount)
+	}
+
+	texts := func(indices []int) string {
+		var parts []string
+		for _, i := range indices {
+			parts = append(parts, conv.Messages[i].Text)
+		}
+		return strings.Join(parts, " | ")
+	}
+	wantActive := "add a retry to the client | Added exponential backoff. | cap retries at five | Capped at five attempts."
+	if got := texts(branches[0]); got != wantActive {
+		t.Errorf("active branch = %q, want %q", got, wantActive)
+	}
+	wantAbandoned := "add a retry to the client | Added exponential backoff. | use a circuit breaker instead | Switched to a breaker."
+	if got := texts(branches[1]); got != wantAbandoned {
+		t.Errorf("abandoned branch = %q, want %q", got, wantAbandoned)
+	}
+
+	// Sessions without uuids are one branch
+	flat := Conversation{Messages: []Message{{Role: "user", Text: "hi"}}}
+	if got := len(flat.branches()); got != 1 {
+		t.Errorf("flat conversation has %d branches, want 1", got)
+	}
+}
+
+func TestRenderPreviewBranches(t *testing.T) {
+	conv := parseJSONLFixture(t, forkedSession...)
+	item := buildItems([]Conversation{*conv}, searchOptions{})[0]
+	m := initialModel([]listItem{item}, "breaker", nil)
+
+	// Abandoned branches are still searchable
+	if len(m.filtered) != 1 {
+		t.Fatalf("abandoned branch text should match, got %d results", len(m.filtered))
+	}
+
+	preview := m.renderPreview(item, 100)
+	if strings.Contains(preview, "Switched to a breaker") {
+		t.Error("preview should show the active branch by default")
+	}
+	if !strings.Contains(preview, "Branch:\033[0m 1/2 (active") || !strings.Contains(preview, "2 hits on other branches") {
+		t.Errorf("preview should show the branch and hits elsewhere, got %q", preview)
+	}
+
+	// Ctrl+B is left to the search field
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	m = newM.(model)
+	if m.branch != 0 || m.textInput.Position() != len("breaker")-1 {
+		t.Errorf("ctrl+b should move the search cursor back, got branch %d, cursor %d", m.branch, m.textInput.Position())
+	}
+
+	newM, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}, Alt: true})
+	m = newM.(model)
+	preview = m.renderPreview(item, 100)
+	if !strings.Contains(preview, "2/2 (abandoned") || strings.Contains(preview, "Capped at five") {
+		t.Errorf("alt+n should switch to the abandoned branch, got %q", preview)
+	}
+
+	if line := m.formatListItem(item, true); !strings.Contains(line, "    6    2     2") {
+		t.Errorf("list should show the branch count, got %q", line)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// subcommands run instead of the TUI when named as the first argument
+var subcommands = map[string]func(args []string) error{
+	"archive":      runArchive,
+	"export":       runExport,
+	"list":         runList,
+	"scan-secrets": runScanSecrets,
+	"show":         runShow,
+	"stats":        runStats,
+	"prune":        runPrune,
+	"trash":        runTrash,
+	"activity":     runActivity,
+}
+
+// scanFlags are the flags choosing which conversation files are loaded,
+// shared by the TUI and the subcommands
+type scanFlags struct {
+	maxAgeDays int
+	maxSizeMB  int64
+	rebuild    bool
+	after      time.Time // Also skip files last written before this
+}
+
+func defaultScanFlags() scanFlags {
+	return scanFlags{maxAgeDays: 60, maxSizeMB: 1024} // 60 days, 1GB
+}
+
+// parse applies arg if it is a scan flag and reports whether it was one
+func (f *scanFlags) parse(arg string) bool {
+	switch {
+	case arg == "--all":
+		f.maxAgeDays, f.maxSizeMB = 0, 0
+	case arg == "--rebuild-index":
+		f.rebuild = true
+	case strings.HasPrefix(arg, "--max-age="):
+		fmt.Sscanf(strings.TrimPrefix(arg, "--max-age="), "%d", &f.maxAgeDays)
+	case strings.HasPrefix(arg, "--max-size="):
+		fmt.Sscanf(strings.TrimPrefix(arg, "--max-size="), "%d", &f.maxSizeMB)
+	default:
+		return false
+	}
+	return true
+}
+
+// cutoff is the oldest modification time to load (zero means no limit)
+func (f scanFlags) cutoff() time.Time {
+	cutoff := f.after
+	if f.maxAgeDays > 0 {
+		if maxAge := time.Now().AddDate(0, 0, -f.maxAgeDays); maxAge.After(cutoff) {
+			cutoff = maxAge
+		}
+	}
+	return cutoff
+}
+
+// maxSize is the largest file to load in bytes (0 means no limit)
+func (f scanFlags) maxSize() int64 {
+	return f.maxSizeMB * 1024 * 1024
+}
+
+// load reads the conversations the flags select, through the index
+func (f scanFlags) load(search searchOptions) ([]listItem, error) {
+	projectsDir := getProjectsDir()
+	if _, err := os.Stat(projectsDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("projects directory not found: %s", projectsDir)
+	}
+	return loadItems(f.cutoff(), f.maxSize(), f.rebuild, search)
+}
+
+// filterItems keeps the items matching a search query (exact mode)
+func filterItems(items []listItem, query string) ([]listItem, error) {
+	mt, err := newMatcher(query, modeExact)
+	if err != nil {
+		return nil, err
+	}
+	if mt.empty() {
+		return items, nil
+	}
+	var kept []listItem
+	for _, item := range items {
+		if _, ok := mt.matchItem(item); ok {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+// compactMetadata describes a compact_boundary line
+type compactMetadata struct {
+	Trigger   string `json:"trigger"`   // auto or manual
+	PreTokens int    `json:"preTokens"` // Context size before compacting
+}
+
+// compactionMessage is the marker recorded where a session was compacted
+func compactionMessage(meta compactMetadata, ts string) Message {
+	text := "Conversation compacted"
+	switch {
+	case meta.Trigger != "" && meta.PreTokens > 0:
+		text += fmt.Sprintf(" (%s, %s tokens)", meta.Trigger, formatTokens(meta.PreTokens))
+	case meta.Trigger != "":
+		text += fmt.Sprintf(" (%s)", meta.Trigger)
+	}
+	return Message{Role: "system", Kind: kindCompaction, Text: text, Ts: ts}
+}
+
+// addCompactSummary records the synthetic prompt that carries a compacted
+// session's summary. Older sessions have no boundary line before it, so
+// the summary itself counts as the compaction.
+func (conv *Conversation) addCompactSummary(text, ts string) {
+	if n := len(conv.Messages); n == 0 || conv.Messages[n-1].Kind != kindCompaction {
+		conv.Compactions++
+	}
+	conv.Messages = append(conv.Messages, Message{Role: "user", Kind: kindCompactSummary, Text: text, Ts: ts})
+}
+
+// formatTokens abbreviates a token count, e.g. 155000 as 155k
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var compactedSession = []string{
+	`{"type":"user","uuid":"u1","parentUuid":null,"cwd":"/src/app","message":{"content":"migrate the billing tables"},"timestamp":"2024-01-15T10:00:00Z"}`,
+	`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"content":"Wrote the migration."},"timestamp":"2024-01-15T10:01:00Z"}`,
+	`{"type":"system","subtype":"compact_boundary","uuid":"c1","parentUuid":null,"logicalParentUuid":"a1","content":"Conversation compacted","compactMetadata":{"trigger":"auto","preTokens":155000},"timestamp":"2024-01-15T11:00:00Z"}`,
+	`{"type":"user","uuid":"s1","parentUuid":"c1","isCompactSummary":true,"message":{"content":"This session is being continued from a previous conversation. Summary: billing migration written."},"timestamp":"2024-01-15T11:00:01Z"}`,
+	`{"type":"user","uuid":"u2","parentUuid":"s1","message":{"content":"now backfill the data"},"timestamp":"2024-01-15T11:01:00Z"}`,
+	`{"type":"assistant","uuid":"a2","parentUuid":"u2","message":{"content":"Backfill done."},"timestamp":"2024-01-15T11:02:00Z"}`,
+}
+
+func TestParseCompaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []string
+		kinds       string
+		compactions int
+		topic       string
+	}{
+		{"boundary", compactedSession, "user/ assistant/ system/compaction user/compact_summary user/ assistant/", 1, "migrate the billing tables"},
+		// A compacted session's first prompt can be the summary itself, and
+		// a summary without a boundary still counts as a compaction
+		{"summary only", []string{
+			`{"type":"user","isCompactSummary":true,"message":{"content":"This session is being continued..."},"timestamp":"2024-01-15T11:00:01Z"}`,
+			`{"type":"user","cwd":"/src/app","message":{"content":"now backfill the data"},"timestamp":"2024-01-15T11:01:00Z"}`,
+		}, "user/compact_summary user/", 1, "now backfill the data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := parseJSONLFixture(t, tt.lines...)
+			if got := messageKinds(conv); got != tt.kinds {
+				t.Errorf("message kinds = %q, want %q", got, tt.kinds)
+			}
+			if conv.Compactions != tt.compactions {
+				t.Errorf("Compactions = %d, want %d", conv.Compactions, tt.compactions)
+			}
+			if got := getTopic(*conv); got != tt.topic {
+				t.Errorf("topic = %q, want %q", got, tt.topic)
+			}
+			if conv.BranchCount != 1 {
+				t.Errorf("compaction should not fork the conversation, got %d branches", conv.BranchCount)
+			}
+		})
+	}
+
+	conv := parseJSONLFixture(t, compactedSession...)
+	if got := conv.Messages[2].Text; got != "Conversation compacted (auto, 155k tokens)" {
+		t.Errorf("boundary text = %q", got)
+	}
+}
+
+func TestCompactionQueryAndPreview(t *testing.T) {
+	compacted := parseJSONLFixture(t, compactedSession...)
+	plain := parseJSONLFixture(t, toolSession...)
+	items := buildItems([]Conversation{*compacted, *plain}, searchOptions{})
+
+	m := initialModel(items, "is:compacted", nil)
+	if len(m.filtered) != 1 || m.filtered[0].conv.Compactions == 0 {
+		t.Errorf("is:compacted should keep only the compacted session, got %d", len(m.filtered))
+	}
+	if _, err := newMatcher("is:bogus", modeExact); err == nil {
+		t.Error("unknown is: value should be an error")
+	}
+
+	// The boundary isn't searchable text
+	if strings.Contains(items[0].searchText, "Conversation compacted") {
+		t.Error("compaction markers should not be searchable")
+	}
+
+	preview := m.renderPreview(items[0], 100)
+	separator := "──── " + formatTimestamp("2024-01-15T11:00:00Z") + " Conversation compacted (auto, 155k tokens) ────"
+	if !strings.Contains(preview, separator) {
+		t.Errorf("preview should show the compaction separator, got %q", preview)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{950, "950"},
+		{155000, "155k"},
+		{1250000, "1.2M"},
+	}
+	for _, tt := range tests {
+		if got := formatTokens(tt.n); got != tt.want {
+			t.Errorf("formatTokens(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// getConfigDir returns the directory ccs reads its config file from
+// Declared as a variable so it can be overridden in tests
+var getConfigDir = func() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ccs")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "ccs")
+}
+
+func configPath() string {
+	return filepath.Join(getConfigDir(), "config.json")
+}
+
+// config is the optional user configuration in config.json
+type config struct {
+	// Prices maps a model name, or part of one, to its price. Entries
+	// override and extend defaultPrices.
+	Prices map[string]modelPrice `json:"prices"`
+
+	Redact redactConfig `json:"redact"`
+}
+
+// redactConfig controls secret redaction
+type redactConfig struct {
+	Patterns []string `json:"patterns"` // Regular expressions for secrets the built-in detectors miss
+	Preview  bool     `json:"preview"`  // Also redact the TUI preview
+}
+
+// modelPrice is what a model costs in USD per million tokens
+type modelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// defaultPrices are list prices by model family, used for estimates when
+// the config doesn't name the model. Opus prices change between versions,
+// so each is listed and a newer one stays unpriced rather than guessed.
+var defaultPrices = map[string]modelPrice{
+	"claude-3-opus":          {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-opus-4-20250514": {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-opus-4-1":        {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-opus-4-5":        {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"sonnet":                 {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"haiku":                  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+}
+
+// loadConfig reads config.json. A missing file is an empty config; an
+// unreadable or malformed one is an error.
+func loadConfig() (config, error) {
+	var cfg config
+	data, err := os.ReadFile(configPath())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", configPath(), err)
+	}
+	return cfg, nil
+}
+
+// price finds the price of model: an exact entry, else the longest entry
+// contained in the name, from the config before the defaults
+func (c config) price(model string) (modelPrice, bool) {
+	for _, table := range []map[string]modelPrice{c.Prices, defaultPrices} {
+		if p, ok := table[model]; ok {
+			return p, true
+		}
+		best := ""
+		for name := range table {
+			if len(name) > len(best) && strings.Contains(model, name) {
+				best = name
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	return modelPrice{}, false
+}
+
+// cost estimates what usage cost in USD
+func (p modelPrice) cost(u tokenUsage) float64 {
+	return (float64(u.Input)*p.Input +
+		float64(u.Output)*p.Output +
+		float64(u.CacheCreation)*p.CacheWrite +
+		float64(u.CacheRead)*p.CacheRead) / 1e6
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	oldGetConfigDir := getConfigDir
+	getConfigDir = func() string { return dir }
+	t.Cleanup(func() { getConfigDir = oldGetConfigDir })
+	path := filepath.Join(dir, "config.json")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("missing config should not be an error: %v", err)
+	}
+	if p, ok := cfg.price("claude-sonnet-4-5-20250929"); !ok || p.Input != 3 {
+		t.Errorf("default sonnet price = %+v, %v", p, ok)
+	}
+
+	os.WriteFile(path, []byte(`{"prices":{"sonnet":{"input":4,"output":20},"my-local-model":{"input":0.5}}}`), 0644)
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	tests := []struct {
+		model string
+		input float64
+		ok    bool
+	}{
+		{"claude-sonnet-4-5", 4, true}, // Config overrides the default
+		{"my-local-model", 0.5, true},  // Config adds models
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-opus-4-20250514", 15, true},
+		{"claude-3-opus-20240229", 15, true},
+		{"claude-opus-4-6", 0, false}, // Unlisted Opus versions aren't guessed
+		{"gpt-4", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := cfg.price(tt.model)
+		if ok != tt.ok || p.Input != tt.input {
+			t.Errorf("price(%q) = %v, %v, want input %v, %v", tt.model, p.Input, ok, tt.input, tt.ok)
+		}
+	}
+
+	os.WriteFile(path, []byte(`{"prices":`), 0644)
+	if _, err := loadConfig(); err == nil {
+		t.Error("malformed config should be an error")
+	}
+}
+
+func TestModelPriceCost(t *testing.T) {
+	p := modelPrice{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	u := tokenUsage{Input: 1_000_000, Output: 100_000, CacheCreation: 0, CacheRead: 1_000_000}
+	if got := p.cost(u); got < 4.79 || got > 4.81 {
+		t.Errorf("cost = %v, want 4.80", got)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportFormat is a document format conversations can be exported to
+type exportFormat struct {
+	ext    string
+	render func(conv Conversation, messages []Message) string
+}
+
+// exportFormats are the formats `ccs export` and Alt+E can write
+var exportFormats = map[string]exportFormat{
+	"markdown": {ext: ".md", render: renderMarkdown},
+	"html":     {ext: ".html", render: renderHTML},
+}
+
+// exportFileName names the file a conversation is exported to, after its
+// project and session
+func exportFileName(conv Conversation, format exportFormat) string {
+	id := conv.SessionID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return filepath.Base(conv.Cwd) + "-" + id + format.ext
+}
+
+// fence returns a code fence longer than any run of backticks in text
+func fence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// codeBlock wraps text in a fenced code block
+func codeBlock(text, lang string) string {
+	f := fence(text)
+	return f + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + f + "\n"
+}
+
+// frontMatterValue quotes a YAML value; JSON strings are valid YAML
+func frontMatterValue(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// renderMarkdown writes messages as a Markdown document with a front matter
+// header. Text is kept as written, so code blocks survive; tool calls and
+// thinking become collapsible <details> sections.
+func renderMarkdown(conv Conversation, messages []Message) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fields := [][2]string{
+		{"title", truncate(getTopic(conv), 200)},
+		{"project", conv.Cwd},
+		{"session", conv.SessionID},
+		{"started", conv.FirstTimestamp},
+		{"updated", conv.LastTimestamp},
+		{"branch", conv.GitBranch},
+		{"model", strings.Join(conv.Models, ", ")},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field[0], frontMatterValue(field[1]))
+		}
+	}
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n", truncate(getTopic(conv), 100))
+
+	results := toolResults(messages)
+	heading := ""
+	for _, msg := range messages {
+		if isPaired(results, msg) {
+			continue
+		}
+		if msg.Kind == kindCompaction {
+			fmt.Fprintf(&b, "\n---\n\n*%s (%s)*\n", msg.Text, formatTimestamp(msg.Ts))
+			continue
+		}
+
+		// A user prompt starts a User section; Claude's replies, thinking
+		// and tool traffic all belong to the Claude section
+		section := "Claude"
+		if msg.Role == "user" && msg.Kind != kindCompactSummary {
+			section = "User"
+		}
+		if section != heading && msg.Kind != kindCompactSummary {
+			fmt.Fprintf(&b, "\n## %s\n", section)
+			heading = section
+		}
+
+		b.WriteString("\n")
+		switch msg.Kind {
+		case kindThinking:
+			writeDetails(&b, "Thinking", msg.Text+"\n")
+		case kindCompactSummary:
+			writeDetails(&b, "Compaction summary", msg.Text+"\n")
+		case kindToolUse:
+			body := codeBlock(indentJSON(msg.Tool.Input), "json")
+			if result, ok := results[msg.Tool.ID]; ok {
+				label := "Output"
+				if result.Tool.IsError {
+					label = "Error"
+				}
+				body += "\n" + label + ":\n\n" + codeBlock(result.Text, "")
+			}
+			writeDetails(&b, msg.Tool.Name+": "+truncate(toolSummary(msg.Tool), 80), body)
+		case kindToolResult:
+			writeDetails(&b, "Result", codeBlock(msg.Text, ""))
+		default:
+			b.WriteString(strings.TrimRight(msg.Text, "\n") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// toolResults indexes the results of the tool calls among messages by call
+// ID. Exports show a result inside the section of the call it answers
+// rather than on its own.
+func toolResults(messages []Message) map[string]Message {
+	calls := make(map[string]bool)
+	for _, msg := range messages {
+		if msg.Kind == kindToolUse && msg.Tool != nil && msg.Tool.ID != "" {
+			calls[msg.Tool.ID] = true
+		}
+	}
+	results := make(map[string]Message)
+	for _, msg := range messages {
+		if msg.Kind == kindToolResult && msg.Tool != nil && calls[msg.Tool.ID] {
+			results[msg.Tool.ID] = msg
+		}
+	}
+	return results
+}
+
+// isPaired reports whether msg is a result shown with its call
+func isPaired(results map[string]Message, msg Message) bool {
+	if msg.Kind != kindToolResult || msg.Tool == nil {
+		return false
+	}
+	_, ok := results[msg.Tool.ID]
+	return ok
+}
+
+// writeDetails writes a collapsible HTML section around Markdown body
+func writeDetails(b *strings.Builder, summary, body string) {
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n%s\n</details>\n", html.EscapeString(summary), body)
+}
+
+// indentJSON pretty-prints JSON, returning anything else unchanged
+func indentJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+// exportConversation writes the messages of one branch to a file named by
+// exportFileName in dir, returning its path
+func exportConversation(conv Conversation, messages []Message, format exportFormat, dir string) (string, error) {
+	path := filepath.Join(dir, exportFileName(conv, format))
+	return path, os.WriteFile(path, []byte(format.render(conv, messages)), 0644)
+}
+
+// exportSelected exports the marked conversations, else the selected one,
+// to the current directory as shown in the preview: the branch being
+// browsed, with thinking if it is shown
+func (m *model) exportSelected(format exportFormat) {
+	targets := m.actionTargets()
+	var paths, failures []string
+	for _, item := range targets {
+		conv := item.conv
+		branch := m.selectedBranch(conv, conv.BranchCount)
+		conv = m.redactor.conversation(conv)
+		path, err := exportConversation(conv, transcriptMessages(conv, branch, true, m.showThinking), format, ".")
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		paths = append(paths, path)
+	}
+	m.errorMsg = bulkFailures("Export", len(targets), failures)
+	switch {
+	case len(paths) == 1:
+		m.statusMsg = "Exported to " + paths[0]
+	case len(paths) > 1:
+		m.statusMsg = fmt.Sprintf("Exported %d conversations to the current directory", len(paths))
+	}
+}
+
+// runExport implements `ccs export`: one conversation as a document
+func runExport(args []string) error {
+	var ref, output string
+	format := exportFormats["markdown"]
+	thinking, redact := false, true
+	for _, arg := range args {
+		switch {
+		case arg == "--no-redact":
+			redact = false
+		case strings.HasPrefix(arg, "--format="):
+			name := strings.TrimPrefix(arg, "--format=")
+			f, ok := exportFormats[name]
+			if !ok {
+				return fmt.Errorf("invalid --format value %q (want markdown or html)", name)
+			}
+			format = f
+		case strings.HasPrefix(arg, "--output="):
+			output = strings.TrimPrefix(arg, "--output=")
+		case arg == "--thinking":
+			thinking = true
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		case ref != "":
+			return fmt.Errorf("expected one session, got %q and %q", ref, arg)
+		default:
+			ref = arg
+		}
+	}
+	if ref == "" {
+		return fmt.Errorf("usage: ccs export [--format=markdown|html] [--output=FILE] [--thinking] [--no-redact] <session-id|file>")
+	}
+
+	r, err := loadRedactor(redact)
+	if err != nil {
+		return err
+	}
+	conv, err := loadSession(ref)
+	if err != nil {
+		return err
+	}
+	*conv = r.conversation(*conv)
+	doc := format.render(*conv, transcriptMessages(*conv, 0, true, thinking))
+	if output == "" || output == "-" {
+		fmt.Print(doc)
+		return nil
+	}
+	return os.WriteFile(output, []byte(doc), 0644)
+}
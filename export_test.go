@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	call := &ToolCall{ID: "t1", Name: "Bash", Input: `{"command":"go test ./..."}`}
+	conv := Conversation{
+		SessionID:      "3f2a9c41-aaaa-bbbb",
+		Cwd:            "/src/api",
+		FirstTimestamp: "2024-01-15T10:00:00Z",
+		LastTimestamp:  "2024-01-15T11:00:00Z",
+		GitBranch:      "fix/login",
+		Models:         []string{"claude-opus-4-5"},
+		Messages: []Message{
+			{Role: "user", Text: "Why does login fail?"},
+			{Role: "assistant", Kind: kindThinking, Text: "Check the tests"},
+			{Role: "assistant", Text: "Running the tests:\n\n```go\nfunc f() {}\n```"},
+			{Role: "assistant", Kind: kindToolUse, Text: "Bash", Tool: call},
+			{Role: "tool", Kind: kindToolResult, Text: "FAIL <login>", Tool: &ToolCall{ID: "t1", Name: "Bash", IsError: true}},
+			{Role: "assistant", Text: "Found it."},
+			{Kind: kindCompaction, Text: "Context compacted"},
+			{Role: "user", Kind: kindCompactSummary, Text: "Summary of earlier work"},
+			{Role: "user", Text: "Thanks"},
+		},
+	}
+	doc := renderMarkdown(conv, conv.Messages)
+
+	for _, want := range []string{
+		"---\ntitle: \"Why does login fail?\"\nproject: \"/src/api\"\nsession: \"3f2a9c41-aaaa-bbbb\"\n",
+		"branch: \"fix/login\"\nmodel: \"claude-opus-4-5\"\n---\n\n# Why does login fail?\n",
+		"\n## User\n\nWhy does login fail?\n\n## Claude\n\n<details>\n<summary>Thinking</summary>",
+		"```go\nfunc f() {}\n```",
+		"<summary>Bash: go test ./...</summary>\n\n```json\n{\n  \"command\": \"go test ./...\"\n}\n```\n\nError:\n\n```\nFAIL <login>\n```\n\n</details>",
+		"\n---\n\n*Context compacted",
+		"<summary>Compaction summary</summary>",
+		"\n## User\n\nThanks\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document should contain %q:\n%s", want, doc)
+		}
+	}
+	if strings.Count(doc, "## Claude") != 1 {
+		t.Errorf("replies and tool calls should share one Claude section:\n%s", doc)
+	}
+	if strings.Count(doc, "FAIL") != 1 {
+		t.Errorf("the tool result should only appear inside its call:\n%s", doc)
+	}
+}
+
+func TestFence(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "```"},
+		{"has ``` inside", "````"},
+		{"`a` and ````b````", "`````"},
+	}
+	for _, tt := range tests {
+		if got := fence(tt.text); got != tt.want {
+			t.Errorf("fence(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExportKey(t *testing.T) {
+	dir := t.TempDir()
+	old, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(old)
+
+	conv := Conversation{
+		SessionID: "3f2a9c41-aaaa-bbbb",
+		Cwd:       "/src/api",
+		Messages: []Message{
+			{Role: "user", Text: "Why does login fail?"},
+			{Role: "assistant", Kind: kindThinking, Text: "Check the tests"},
+			{Role: "assistant", Text: "Found it."},
+		},
+	}
+	m := initialModel([]listItem{{conv: conv}}, "", nil)
+	// Ctrl+E is left to the search field
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	m = updated.(model)
+	if m.statusMsg != "" {
+		t.Fatalf("ctrl+e should not export, got status %q", m.statusMsg)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}, Alt: true})
+	m = updated.(model)
+	if m.errorMsg != "" || !strings.Contains(m.statusMsg, "api-3f2a9c41.md") {
+		t.Fatalf("status = %q, error = %q", m.statusMsg, m.errorMsg)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "api-3f2a9c41.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Thinking") {
+		t.Error("thinking hidden in the preview should be left out of the export")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if updated.(model).statusMsg != "" {
+		t.Error("the status should clear on the next key")
+	}
+}
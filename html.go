@@ -0,0 +1,170 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+)
+
+// htmlMessage is one message as the HTML export template shows it
+type htmlMessage struct {
+	Class       string // user, assistant, thinking, tool, summary or compaction
+	Label       string
+	Time        string
+	Text        string
+	Input       string // Tool call input
+	Error       bool   // Tool call failed
+	Collapsible bool
+}
+
+// htmlDocument is the data the HTML export template renders
+type htmlDocument struct {
+	Title    string
+	Version  string
+	Meta     [][2]string
+	Messages []htmlMessage
+}
+
+// renderHTML writes messages as a single self-contained HTML page: chat
+// bubbles, collapsible tool calls and thinking, and a search box
+func renderHTML(conv Conversation, messages []Message) string {
+	doc := htmlDocument{Title: truncate(getTopic(conv), 100), Version: version}
+	meta := [][2]string{
+		{"Project", conv.Cwd},
+		{"Session", conv.SessionID},
+		{"Started", formatTimestamp(conv.FirstTimestamp)},
+		{"Updated", formatTimestamp(conv.LastTimestamp)},
+		{"Git branch", conv.GitBranch},
+		{"Model", strings.Join(conv.Models, ", ")},
+	}
+	if usage := conv.totalUsage(); usage.total() > 0 {
+		meta = append(meta, [2]string{"Tokens", formatTokens(usage.total())})
+	}
+	for _, field := range meta {
+		if field[1] != "" {
+			doc.Meta = append(doc.Meta, field)
+		}
+	}
+
+	results := toolResults(messages)
+	for _, msg := range messages {
+		if isPaired(results, msg) {
+			continue
+		}
+		hm := htmlMessage{Time: formatTimestamp(msg.Ts), Text: msg.Text}
+		label, _ := messageLabel(msg)
+		switch {
+		case msg.Kind == kindCompaction:
+			hm.Class = "compaction"
+		case msg.Kind == kindThinking:
+			hm.Class, hm.Label, hm.Collapsible = "thinking", label, true
+		case msg.Kind == kindCompactSummary:
+			hm.Class, hm.Label, hm.Collapsible = "summary", label, true
+		case msg.Kind == kindToolUse:
+			hm.Class, hm.Collapsible = "tool", true
+			hm.Label = msg.Tool.Name + ": " + truncate(toolSummary(msg.Tool), 100)
+			hm.Input, hm.Text = indentJSON(msg.Tool.Input), ""
+			if result, ok := results[msg.Tool.ID]; ok {
+				hm.Text, hm.Error = result.Text, result.Tool.IsError
+			}
+		case msg.Kind == kindToolResult:
+			hm.Class, hm.Label, hm.Collapsible = "tool", label, true
+			hm.Error = msg.Tool != nil && msg.Tool.IsError
+		case msg.Role == "user":
+			hm.Class, hm.Label = "user", label
+		default:
+			hm.Class, hm.Label = "assistant", label
+		}
+		doc.Messages = append(doc.Messages, hm)
+	}
+
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, doc); err != nil {
+		return "<!-- " + template.HTMLEscapeString(err.Error()) + " -->\n"
+	}
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="ccs v{{.Version}}">
+<title>{{.Title}}</title>
+<style>
+:root { --bg: #fff; --fg: #1f2328; --muted: #656d76; --user: #ddf4e4; --assistant: #f0f3f6; --tool: #f6f0fa; --error: #ffebe9; --hit: #fff8c5; --border: #d0d7de; }
+@media (prefers-color-scheme: dark) {
+  :root { --bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --user: #12361f; --assistant: #161b22; --tool: #231a2e; --error: #3c1618; --hit: #4b3d00; --border: #30363d; }
+}
+* { box-sizing: border-box; }
+body { margin: 0; background: var(--bg); color: var(--fg); font: 15px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+header { position: sticky; top: 0; background: var(--bg); border-bottom: 1px solid var(--border); padding: 12px 24px; z-index: 1; }
+h1 { font-size: 20px; margin: 0 0 6px; }
+.meta { display: flex; flex-wrap: wrap; gap: 4px 16px; margin: 0 0 8px; color: var(--muted); font-size: 13px; }
+.meta b { font-weight: 600; }
+.search { display: flex; gap: 8px; align-items: center; }
+.search input { flex: 0 1 360px; padding: 5px 8px; border: 1px solid var(--border); border-radius: 6px; background: var(--bg); color: var(--fg); font: inherit; }
+.search span { color: var(--muted); font-size: 13px; }
+main { max-width: 960px; margin: 0 auto; padding: 16px 24px 48px; display: flex; flex-direction: column; gap: 10px; }
+.msg { border-radius: 12px; padding: 8px 14px; max-width: 85%; border: 1px solid transparent; }
+.msg.hit { border-color: #d4a72c; box-shadow: 0 0 0 3px var(--hit); }
+.msg.dim { opacity: 0.35; }
+.who { font-size: 12px; font-weight: 600; color: var(--muted); margin-bottom: 2px; }
+time { font-weight: normal; color: var(--muted); font-size: 12px; margin-left: 6px; }
+.text, pre { white-space: pre-wrap; overflow-wrap: anywhere; margin: 0; }
+pre { font: 13px/1.45 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; padding: 8px; border-radius: 6px; background: var(--bg); margin-top: 6px; }
+.user { align-self: flex-end; background: var(--user); }
+.assistant { align-self: flex-start; background: var(--assistant); }
+details.msg { align-self: flex-start; background: var(--tool); font-size: 13px; max-width: 100%; }
+details.thinking, details.summary { background: var(--assistant); color: var(--muted); font-style: italic; }
+details.error { background: var(--error); }
+summary { cursor: pointer; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.compaction { align-self: stretch; max-width: none; text-align: center; color: #9a6700; font-size: 13px; border-top: 1px dashed #d4a72c; border-radius: 0; padding-top: 4px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<div class="meta">{{range .Meta}}<span><b>{{index . 0}}:</b> {{index . 1}}</span>{{end}}</div>
+<div class="search"><input id="q" type="search" placeholder="Search this conversation (Enter for next match)"><span id="count"></span></div>
+</header>
+<main>
+{{- range .Messages}}
+{{if eq .Class "compaction"}}<div class="msg compaction">{{.Text}}<time>{{.Time}}</time></div>
+{{- else if .Collapsible}}<details class="msg {{.Class}}{{if .Error}} error{{end}}"><summary>{{.Label}}<time>{{.Time}}</time></summary>
+{{- if .Input}}<pre>{{.Input}}</pre>{{end}}{{if .Text}}<pre>{{.Text}}</pre>{{end}}</details>
+{{- else}}<div class="msg {{.Class}}"><div class="who">{{.Label}}<time>{{.Time}}</time></div><div class="text">{{.Text}}</div></div>
+{{- end}}
+{{- end}}
+</main>
+<script>
+const q = document.getElementById("q"), count = document.getElementById("count");
+const msgs = Array.from(document.querySelectorAll(".msg"));
+let hits = [], current = -1;
+function jump() {
+  if (hits.length === 0) return;
+  current = (current + 1) % hits.length;
+  hits[current].scrollIntoView({block: "center"});
+  count.textContent = (current + 1) + " of " + hits.length + " matching messages";
+}
+q.addEventListener("input", () => {
+  const term = q.value.trim().toLowerCase();
+  hits = [];
+  current = -1;
+  for (const el of msgs) {
+    const hit = term !== "" && el.textContent.toLowerCase().includes(term);
+    el.classList.toggle("hit", hit);
+    el.classList.toggle("dim", term !== "" && !hit);
+    if (hit) {
+      hits.push(el);
+      if (el.tagName === "DETAILS") el.open = true;
+    }
+  }
+  count.textContent = term === "" ? "" : hits.length + " matching messages";
+  jump();
+});
+q.addEventListener("keydown", e => { if (e.key === "Enter") jump(); });
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	call := &ToolCall{ID: "t1", Name: "Bash", Input: `{"command":"go test ./..."}`}
+	conv := Conversation{
+		SessionID:      "3f2a9c41-aaaa-bbbb",
+		Cwd:            "/src/api",
+		FirstTimestamp: "2024-01-15T10:00:00Z",
+		LastTimestamp:  "2024-01-15T11:00:00Z",
+		GitBranch:      "fix/login",
+		Models:         []string{"claude-opus-4-5"},
+		Messages: []Message{
+			{Role: "user", Text: "Why does login fail?"},
+			{Role: "assistant", Kind: kindThinking, Text: "Check the tests"},
+			{Role: "assistant", Text: "Running the tests:\n\n```go\nfunc f() {}\n```"},
+			{Role: "assistant", Kind: kindToolUse, Text: "Bash", Tool: call},
+			{Role: "tool", Kind: kindToolResult, Text: "FAIL <login>", Tool: &ToolCall{ID: "t1", Name: "Bash", IsError: true}},
+			{Role: "assistant", Text: "Found it."},
+			{Kind: kindCompaction, Text: "Context compacted"},
+			{Role: "user", Kind: kindCompactSummary, Text: "Summary of earlier work"},
+			{Role: "user", Text: "<script>alert(1)</script>"},
+		},
+	}
+	doc := renderHTML(conv, conv.Messages)
+
+	for _, want := range []string{
+		"<title>Why does login fail?</title>",
+		"<b>Git branch:</b> fix/login",
+		`<input id="q" type="search"`,
+		`<div class="msg user"><div class="who">User<time>`,
+		`<details class="msg thinking"><summary>Thinking<time>`,
+		`<details class="msg tool error"><summary>Bash: go test ./...<time>`,
+		"FAIL &lt;login&gt;",
+		`<div class="msg compaction">Context compacted`,
+		`<details class="msg summary"><summary>Compaction summary`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("page should contain %q", want)
+		}
+	}
+	if strings.Count(doc, "FAIL") != 1 {
+		t.Error("the tool result should only appear inside its call")
+	}
+	if strings.Contains(doc, "<link") || strings.Contains(doc, "src=") {
+		t.Error("the page should not load anything")
+	}
+}
+
+func TestToolResults(t *testing.T) {
+	call := &ToolCall{ID: "t1", Name: "Read"}
+	messages := []Message{
+		{Kind: kindToolUse, Tool: call},
+		{Kind: kindToolResult, Text: "paired", Tool: &ToolCall{ID: "t1"}},
+		{Kind: kindToolResult, Text: "orphan", Tool: &ToolCall{ID: "t2"}},
+	}
+	results := toolResults(messages)
+	if len(results) != 1 || results["t1"].Text != "paired" {
+		t.Errorf("results = %+v", results)
+	}
+	if !isPaired(results, messages[1]) || isPaired(results, messages[2]) {
+		t.Error("a result whose call isn't exported should be shown on its own")
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexVersion is bumped whenever the cached data layout or parser output
+// changes, so stale caches are discarded instead of misread
+const indexVersion = 13
+
+// getCacheDir returns the directory ccs keeps its search index in
+// Declared as a variable so it can be overridden in tests
+var getCacheDir = func() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "ccs")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "ccs")
+}
+
+// indexEntry is the cached parse result for a single .jsonl file
+type indexEntry struct {
+	ModTime    time.Time
+	Size       int64
+	Conv       *Conversation // nil when the file has no messages
+	SearchText string
+	State      parseState // Where to resume parsing when the file grows
+}
+
+// searchIndex maps conversation file paths to their cached parse results
+type searchIndex struct {
+	Version int
+	Search  searchOptions // What the cached SearchText was built from
+	Entries map[string]*indexEntry
+
+	dirty bool
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		Version: indexVersion,
+		Entries: make(map[string]*indexEntry),
+	}
+}
+
+func indexPath() string {
+	return filepath.Join(getCacheDir(), "index.gob")
+}
+
+// loadIndex reads the on-disk index, returning an empty one if it is
+// missing, unreadable or written by an incompatible version
+func loadIndex() *searchIndex {
+	file, err := os.Open(indexPath())
+	if err != nil {
+		return newSearchIndex()
+	}
+	defer file.Close()
+
+	idx := newSearchIndex()
+	if err := gob.NewDecoder(file).Decode(idx); err != nil || idx.Version != indexVersion {
+		return newSearchIndex()
+	}
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]*indexEntry)
+	}
+	return idx
+}
+
+// save writes the index atomically (temp file + rename) if it changed
+func (idx *searchIndex) save() error {
+	if !idx.dirty {
+		return nil
+	}
+	dir := getCacheDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "index-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), indexPath()); err != nil {
+		return err
+	}
+	idx.dirty = false
+	return nil
+}
+
+// fresh reports whether a cached entry still describes the file on disk
+func (e *indexEntry) fresh(info os.FileInfo) bool {
+	return e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// scanResult pairs a freshly parsed entry with the file it came from
+type scanResult struct {
+	path  string
+	entry *indexEntry
+}
+
+// listConversationFiles returns every session and sub-agent file under dir,
+// compressed ones included
+func listConversationFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && (strings.HasSuffix(path, ".jsonl") || isArchived(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// listAllConversationFiles returns the files in the projects directory
+// followed by those in the archive
+func listAllConversationFiles() ([]string, error) {
+	files, err := listConversationFiles(getProjectsDir())
+	if err != nil {
+		return nil, err
+	}
+	archived, err := listConversationFiles(getArchiveDir())
+	if err != nil {
+		return nil, err
+	}
+	return append(files, archived...), nil
+}
+
+// scanConversations walks the projects directory and the archive and
+// returns an entry for every conversation passing the cutoff and size
+// filters. When idx is non-nil, unchanged files are served from it, changed
+// files are re-parsed (from where the last parse stopped, if they were only
+// appended to) and stored back, and entries for files that no longer exist
+// are dropped.
+func scanConversations(idx *searchIndex, cutoff time.Time, maxSize int64) ([]*indexEntry, error) {
+	files, err := listAllConversationFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*indexEntry
+	var stale []string
+	previous := make(map[string]*indexEntry)
+	infos := make(map[string]os.FileInfo, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		infos[path] = info
+
+		// Same filters as parseConversationFile, applied before touching the cache
+		if maxSize > 0 && info.Size() > maxSize {
+			continue
+		}
+		if !cutoff.IsZero() && info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if idx != nil {
+			if entry, ok := idx.Entries[path]; ok {
+				if entry.fresh(info) {
+					entries = append(entries, entry)
+					continue
+				}
+				previous[path] = entry
+			}
+		}
+		stale = append(stale, path)
+	}
+
+	// Worker pool to limit concurrent file operations
+	const numWorkers = 8
+	jobs := make(chan string, len(stale))
+	results := make(chan scanResult, len(stale))
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				var prevConv *Conversation
+				var prevState parseState
+				if prev, ok := previous[path]; ok {
+					prevConv, prevState = prev.Conv, prev.State
+				}
+				conv, state, err := resumeConversationFile(path, prevConv, prevState)
+				if err != nil {
+					continue
+				}
+				info := infos[path]
+				entry := &indexEntry{ModTime: info.ModTime(), Size: info.Size(), Conv: conv, State: state}
+				if conv != nil && idx != nil {
+					entry.SearchText = buildSearchText(*conv, idx.Search)
+				}
+				results <- scanResult{path, entry}
+			}
+		}()
+	}
+
+	for _, path := range stale {
+		jobs <- path
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if idx != nil {
+			idx.Entries[r.path] = r.entry
+			idx.dirty = true
+		}
+		entries = append(entries, r.entry)
+	}
+
+	// Forget files that have been deleted since the last scan
+	if idx != nil {
+		for path := range idx.Entries {
+			if _, ok := infos[path]; !ok {
+				delete(idx.Entries, path)
+				idx.dirty = true
+			}
+		}
+	}
+
+	// Drop files without messages (they stay cached so they aren't re-parsed)
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.Conv != nil {
+			kept = append(kept, entry)
+		}
+	}
+	entries = attachAgents(idx, kept)
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Conv.LastTimestamp > entries[j].Conv.LastTimestamp
+	})
+
+	return entries, nil
+}
+
+// loadItems builds list items for all conversations, using the on-disk
+// index so only new or modified files are parsed. rebuild discards the
+// existing index and rescans everything.
+func loadItems(cutoff time.Time, maxSize int64, rebuild bool, search searchOptions) ([]listItem, error) {
+	idx := newSearchIndex()
+	if !rebuild {
+		idx = loadIndex()
+	}
+	idx.dirty = idx.dirty || rebuild
+
+	// Different search options only need the cached text rebuilt, not the files
+	if idx.Search != search {
+		idx.Search = search
+		for _, entry := range idx.Entries {
+			if entry.Conv != nil {
+				entry.SearchText = buildSearchText(*entry.Conv, search)
+			}
+		}
+		idx.dirty = true
+	}
+
+	entries, err := scanConversations(idx, cutoff, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed cache write only costs speed on the next launch
+	if err := idx.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save search index: %v\n", err)
+	}
+
+	items := make([]listItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, listItem{
+			conv:       *entry.Conv,
+			searchText: entry.SearchText,
+		})
+	}
+
+	// Stars, tags and notes are ccs's own, so they aren't cached with the files
+	store, err := loadAnnotations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load annotations: %v\n", err)
+	}
+	annotate(items, store)
+	return items, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupIndexTest points the projects, data and cache directories at temp
+// dirs
+func setupIndexTest(t *testing.T) (projectsDir string) {
+	t.Helper()
+	projectsDir = t.TempDir()
+	dataDir := t.TempDir()
+	cacheDir := t.TempDir()
+
+	oldGetProjectsDir := getProjectsDir
+	oldGetDataDir := getDataDir
+	oldGetCacheDir := getCacheDir
+	getProjectsDir = func() string { return projectsDir }
+	getDataDir = func() string { return dataDir }
+	getCacheDir = func() string { return cacheDir }
+	t.Cleanup(func() {
+		getProjectsDir = oldGetProjectsDir
+		getDataDir = oldGetDataDir
+		getCacheDir = oldGetCacheDir
+	})
+	return projectsDir
+}
+
+func TestLoadItemsReusesIndex(t *testing.T) {
+	dir := setupIndexTest(t)
+	file := filepath.Join(dir, "session-1.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"alpha"},"timestamp":"2024-01-15T10:00:00Z"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	mtime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if len(items) != 1 || !strings.Contains(items[0].searchText, "alpha") {
+		t.Fatalf("expected one item containing 'alpha', got %+v", items)
+	}
+	if _, err := os.Stat(indexPath()); err != nil {
+		t.Fatalf("index file should be written: %v", err)
+	}
+
+	// Same size and mtime: the cached parse must be used
+	changed := strings.Replace(content, "alpha", "gamma", 1)
+	if err := os.WriteFile(file, []byte(changed), 0644); err != nil {
+		t.Fatalf("failed to rewrite file: %v", err)
+	}
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	items, err = loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if !strings.Contains(items[0].searchText, "alpha") {
+		t.Errorf("unchanged mtime/size should be served from index, got %q", items[0].searchText)
+	}
+
+	// --rebuild-index ignores the cache
+	items, err = loadItems(time.Time{}, 0, true, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if !strings.Contains(items[0].searchText, "gamma") {
+		t.Errorf("rebuild should re-parse the file, got %q", items[0].searchText)
+	}
+}
+
+func TestLoadItemsReparsesModifiedFiles(t *testing.T) {
+	dir := setupIndexTest(t)
+	file := filepath.Join(dir, "session-1.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"alpha"},"timestamp":"2024-01-15T10:00:00Z"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := loadItems(time.Time{}, 0, false, searchOptions{}); err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+
+	content += "\n" + `{"type":"assistant","message":{"content":"beta"},"timestamp":"2024-01-15T10:01:00Z"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if len(items[0].conv.Messages) != 2 {
+		t.Errorf("modified file should be re-parsed, got %d messages", len(items[0].conv.Messages))
+	}
+}
+
+func TestLoadItemsDropsDeletedFiles(t *testing.T) {
+	dir := setupIndexTest(t)
+	file1 := filepath.Join(dir, "session-1.jsonl")
+	file2 := filepath.Join(dir, "session-2.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}`
+	for _, f := range []string{file1, file2} {
+		if err := os.WriteFile(f, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	if _, err := loadItems(time.Time{}, 0, false, searchOptions{}); err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+
+	if err := os.Remove(file1); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected 1 item after deletion, got %d", len(items))
+	}
+
+	idx := loadIndex()
+	if _, ok := idx.Entries[file1]; ok {
+		t.Error("index should drop entries for deleted files")
+	}
+	if _, ok := idx.Entries[file2]; !ok {
+		t.Error("index should keep entries for existing files")
+	}
+}
+
+func TestLoadItemsKeepsFilteredEntries(t *testing.T) {
+	dir := setupIndexTest(t)
+	file := filepath.Join(dir, "old-session.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	oldTime := time.Now().AddDate(0, 0, -90)
+	if err := os.Chtimes(file, oldTime, oldTime); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	if _, err := loadItems(time.Time{}, 0, false, searchOptions{}); err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+
+	// A narrower --max-age hides the file but must not evict it from the index
+	items, err := loadItems(time.Now().AddDate(0, 0, -30), 0, false, searchOptions{})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("old file should be filtered out, got %d items", len(items))
+	}
+	if _, ok := loadIndex().Entries[file]; !ok {
+		t.Error("filtered files should stay in the index")
+	}
+}
+
+func TestLoadIndexIgnoresCorruptFile(t *testing.T) {
+	setupIndexTest(t)
+	if err := os.MkdirAll(getCacheDir(), 0755); err != nil {
+		t.Fatalf("failed to create cache dir: %v", err)
+	}
+	if err := os.WriteFile(indexPath(), []byte("not a gob"), 0644); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+
+	idx := loadIndex()
+	if len(idx.Entries) != 0 {
+		t.Errorf("corrupt index should load empty, got %d entries", len(idx.Entries))
+	}
+}
+
+func TestLoadItemsRebuildsSearchTextForNewScope(t *testing.T) {
+	dir := setupIndexTest(t)
+	file := filepath.Join(dir, "session-1.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"question"},"timestamp":"2024-01-15T10:00:00Z"}
+{"type":"assistant","message":{"content":"answer"},"timestamp":"2024-01-15T10:01:00Z"}`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	items, err := loadItems(time.Time{}, 0, false, searchOptions{Scope: scopeUser})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if strings.Contains(items[0].searchText, "answer") {
+		t.Errorf("user scope should exclude assistant text, got %q", items[0].searchText)
+	}
+
+	items, err = loadItems(time.Time{}, 0, false, searchOptions{Scope: scopeBoth})
+	if err != nil {
+		t.Fatalf("loadItems failed: %v", err)
+	}
+	if !strings.Contains(items[0].searchText, "answer") {
+		t.Errorf("cached search text should be rebuilt for the new scope, got %q", items[0].searchText)
+	}
+	if loadIndex().Search.Scope != scopeBoth {
+		t.Error("index should record the scope its search text was built with")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listRow is one conversation as printed by `ccs list`
+type listRow struct {
+	item  listItem
+	topic string
+	hits  int // Messages matching the query
+	score int // Match score, higher is better (fuzzy mode)
+}
+
+// listColumn is a field `ccs list` can print
+type listColumn struct {
+	width int                         // Table column width, 0 for unpadded
+	right bool                        // Right-align in tables
+	value func(r listRow) interface{} // Value for TSV and JSON
+}
+
+// listColumns are the selectable --columns
+var listColumns = map[string]listColumn{
+	"date":    {width: 16, value: func(r listRow) interface{} { return r.item.conv.LastTimestamp }},
+	"project": {width: 22, value: func(r listRow) interface{} { return filepath.Base(r.item.conv.Cwd) }},
+	"topic":   {width: 40, value: func(r listRow) interface{} { return r.topic }},
+	"msgs":    {width: 5, right: true, value: func(r listRow) interface{} { return r.item.conv.chatMessageCount() }},
+	"hits":    {width: 4, right: true, value: func(r listRow) interface{} { return r.hits }},
+	"session": {width: 36, value: func(r listRow) interface{} { return r.item.conv.SessionID }},
+	"file":    {value: func(r listRow) interface{} { return r.item.conv.FilePath }},
+}
+
+const listColumnNames = "date, project, topic, msgs, hits, session, file"
+
+// defaultListColumns are printed when --columns isn't given; the
+// machine-readable formats get everything
+var defaultListColumns = map[string][]string{
+	"table": {"date", "project", "topic", "msgs", "hits"},
+	"tsv":   {"date", "project", "topic", "msgs", "hits", "session", "file"},
+	"json":  {"date", "project", "topic", "msgs", "hits", "session", "file"},
+	"jsonl": {"date", "project", "topic", "msgs", "hits", "session", "file"},
+}
+
+// listSorts order rows for --sort; ties keep the newest first order
+var listSorts = map[string]func(a, b listRow) bool{
+	"date":    func(a, b listRow) bool { return a.item.conv.LastTimestamp > b.item.conv.LastTimestamp },
+	"project": func(a, b listRow) bool { return filepath.Base(a.item.conv.Cwd) < filepath.Base(b.item.conv.Cwd) },
+	"msgs":    func(a, b listRow) bool { return a.item.conv.chatMessageCount() > b.item.conv.chatMessageCount() },
+	"hits":    func(a, b listRow) bool { return a.hits > b.hits },
+	"score":   func(a, b listRow) bool { return a.score > b.score },
+}
+
+// listOptions are the flags of `ccs list`
+type listOptions struct {
+	format  string
+	columns []string
+	sort    string // "" sorts by score in fuzzy mode, else by date
+	limit   int    // 0 means no limit
+	mode    searchMode
+	search  searchOptions
+	query   string
+	redact  bool // Mask secrets in topics
+}
+
+// parseListArgs parses the arguments of `ccs list`, scan flags included
+func parseListArgs(args []string, scan *scanFlags) (listOptions, error) {
+	opts := listOptions{format: "table", redact: true}
+	for _, arg := range args {
+		var err error
+		switch {
+		case scan.parse(arg):
+		case strings.HasPrefix(arg, "--format="):
+			opts.format = strings.TrimPrefix(arg, "--format=")
+			if defaultListColumns[opts.format] == nil {
+				return opts, fmt.Errorf("invalid --format value %q (want table, tsv, json or jsonl)", opts.format)
+			}
+		case strings.HasPrefix(arg, "--columns="):
+			opts.columns = strings.Split(strings.TrimPrefix(arg, "--columns="), ",")
+			for _, name := range opts.columns {
+				if _, ok := listColumns[name]; !ok {
+					return opts, fmt.Errorf("invalid column %q (want %s)", name, listColumnNames)
+				}
+			}
+		case strings.HasPrefix(arg, "--sort="):
+			opts.sort = strings.TrimPrefix(arg, "--sort=")
+			if listSorts[opts.sort] == nil {
+				return opts, fmt.Errorf("invalid --sort value %q (want date, project, msgs, hits or score)", opts.sort)
+			}
+		case strings.HasPrefix(arg, "--limit="):
+			if opts.limit, err = strconv.Atoi(strings.TrimPrefix(arg, "--limit=")); err != nil || opts.limit < 0 {
+				return opts, fmt.Errorf("invalid --limit value %q", strings.TrimPrefix(arg, "--limit="))
+			}
+		case strings.HasPrefix(arg, "--mode="):
+			if opts.mode, err = parseMode(strings.TrimPrefix(arg, "--mode=")); err != nil {
+				return opts, err
+			}
+		case strings.HasPrefix(arg, "--scope="):
+			if opts.search.Scope, err = parseScope(strings.TrimPrefix(arg, "--scope=")); err != nil {
+				return opts, err
+			}
+		case arg == "--include-thinking":
+			opts.search.IncludeThinking = true
+		case arg == "--no-redact":
+			opts.redact = false
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown flag %s", arg)
+		default:
+			opts.query = arg
+		}
+	}
+	if opts.columns == nil {
+		opts.columns = defaultListColumns[opts.format]
+	}
+	return opts, nil
+}
+
+// listRows filters and orders items the way the TUI would for the query,
+// then applies --sort and --limit. Topics are masked by r, if non-nil.
+func listRows(items []listItem, opts listOptions, r *redactor) ([]listRow, error) {
+	mt, err := newMatcher(opts.query, opts.mode)
+	if err != nil {
+		return nil, err
+	}
+	mt.search = opts.search
+
+	now := time.Now()
+	var rows []listRow
+	for _, item := range items {
+		score, ok := mt.matchItem(item)
+		if !ok {
+			continue
+		}
+		if opts.mode == modeFuzzy {
+			score += recencyBonus(item.conv.LastTimestamp, now)
+		}
+		rows = append(rows, listRow{item: item, topic: r.redact(getTopic(item.conv)), hits: mt.countHits(item.conv), score: score})
+	}
+
+	by := opts.sort
+	if by == "" {
+		by = "date"
+		if opts.mode == modeFuzzy && !mt.empty() {
+			by = "score"
+		}
+	}
+	less := listSorts[by]
+	sort.SliceStable(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+
+	if opts.limit > 0 && len(rows) > opts.limit {
+		rows = rows[:opts.limit]
+	}
+	return rows, nil
+}
+
+// writeList prints rows in the chosen format
+func writeList(w io.Writer, rows []listRow, opts listOptions) error {
+	switch opts.format {
+	case "json", "jsonl":
+		objects := make([]json.RawMessage, 0, len(rows))
+		for _, row := range rows {
+			obj, err := listObject(row, opts.columns)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, obj)
+		}
+		if opts.format == "jsonl" {
+			for _, obj := range objects {
+				fmt.Fprintf(w, "%s\n", obj)
+			}
+			return nil
+		}
+		data, err := json.MarshalIndent(objects, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", data)
+
+	case "tsv":
+		fmt.Fprintln(w, strings.Join(opts.columns, "\t"))
+		for _, row := range rows {
+			fields := make([]string, len(opts.columns))
+			for i, name := range opts.columns {
+				// Tabs and newlines would break the row
+				fields[i] = strings.Join(strings.Fields(fmt.Sprint(listColumns[name].value(row))), " ")
+			}
+			fmt.Fprintln(w, strings.Join(fields, "\t"))
+		}
+
+	default:
+		header := make([]string, len(opts.columns))
+		for i, name := range opts.columns {
+			header[i] = tableCell(listColumns[name], strings.ToUpper(name))
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(header, "  "), " "))
+		for _, row := range rows {
+			cells := make([]string, len(opts.columns))
+			for i, name := range opts.columns {
+				col := listColumns[name]
+				value := fmt.Sprint(col.value(row))
+				if name == "date" {
+					value = formatTimestamp(value)
+				}
+				cells[i] = tableCell(col, value)
+			}
+			fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+		}
+	}
+	return nil
+}
+
+// tableCell pads or truncates value to the column's width
+func tableCell(col listColumn, value string) string {
+	if col.width == 0 {
+		return value
+	}
+	value = truncate(value, col.width)
+	if col.right {
+		return fmt.Sprintf("%*s", col.width, value)
+	}
+	return fmt.Sprintf("%-*s", col.width, value)
+}
+
+// listObject encodes a row as a JSON object with keys in column order
+func listObject(row listRow, columns []string) (json.RawMessage, error) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, name := range columns {
+		value, err := json.Marshal(listColumns[name].value(row))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%q:%s", name, value)
+	}
+	b.WriteString("}")
+	return json.RawMessage(b.String()), nil
+}
+
+// runList implements `ccs list`: the TUI's search results, for scripts
+func runList(args []string) error {
+	scan := defaultScanFlags()
+	opts, err := parseListArgs(args, &scan)
+	if err != nil {
+		return err
+	}
+	r, err := loadRedactor(opts.redact)
+	if err != nil {
+		return err
+	}
+	items, err := scan.load(opts.search)
+	if err != nil {
+		return err
+	}
+	rows, err := listRows(items, opts, r)
+	if err != nil {
+		return err
+	}
+	return writeList(os.Stdout, rows, opts)
+}
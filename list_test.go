@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseListArgs(t *testing.T) {
+	scan := defaultScanFlags()
+	opts, err := parseListArgs([]string{"--format=json", "--limit=5", "--sort=msgs", "--all", "login"}, &scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.format != "json" || opts.limit != 5 || opts.sort != "msgs" || opts.query != "login" {
+		t.Errorf("opts = %+v", opts)
+	}
+	if len(opts.columns) != 7 {
+		t.Errorf("json should default to every column, got %v", opts.columns)
+	}
+	if scan.maxAgeDays != 0 {
+		t.Error("--all should be applied to the scan flags")
+	}
+
+	for _, args := range [][]string{
+		{"--format=xml"},
+		{"--columns=date,size"},
+		{"--sort=size"},
+		{"--limit=-1"},
+		{"--mode=glob"},
+		{"--dump"},
+	} {
+		if _, err := parseListArgs(args, &scan); err == nil {
+			t.Errorf("parseListArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestListRows(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "s1", Cwd: "/src/web", LastTimestamp: "2024-01-20T10:00:00Z", Messages: []Message{{Role: "user", Text: "fix the\tlogin page"}}},
+		{SessionID: "s2", Cwd: "/src/api", LastTimestamp: "2024-01-19T10:00:00Z", Messages: []Message{
+			{Role: "user", Text: "login bug"}, {Role: "user", Text: "login again"}, {Role: "user", Text: "and more"},
+		}},
+		{SessionID: "s3", Cwd: "/src/api", LastTimestamp: "2024-01-18T10:00:00Z", Messages: []Message{{Role: "user", Text: "deploy"}}},
+	}, searchOptions{})
+
+	rows, err := listRows(items, listOptions{query: "login"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].item.conv.SessionID != "s1" || rows[1].hits != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	rows, _ = listRows(items, listOptions{query: "login", sort: "hits"}, nil)
+	if rows[0].item.conv.SessionID != "s2" {
+		t.Errorf("--sort=hits should put s2 first, got %s", rows[0].item.conv.SessionID)
+	}
+
+	rows, _ = listRows(items, listOptions{sort: "project", limit: 2}, nil)
+	if len(rows) != 2 || rows[0].item.conv.SessionID != "s2" || rows[1].item.conv.SessionID != "s3" {
+		t.Errorf("--sort=project --limit=2 should keep the api sessions newest first, got %+v", rows)
+	}
+
+	if _, err := listRows(items, listOptions{query: "(login"}, nil); err == nil {
+		t.Error("an invalid query should be an error")
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "s1", Cwd: "/src/web", LastTimestamp: "2024-01-20T10:00:00Z", Messages: []Message{{Role: "user", Text: "fix the\tlogin page"}}},
+		{SessionID: "s2", Cwd: "/src/api", LastTimestamp: "2024-01-19T10:00:00Z", Messages: []Message{
+			{Role: "user", Text: "login bug"}, {Role: "user", Text: "login again"}, {Role: "user", Text: "and more"},
+		}},
+	}, searchOptions{})
+	rows, _ := listRows(items, listOptions{query: "login"}, nil)
+
+	var buf bytes.Buffer
+	writeList(&buf, rows, listOptions{format: "json", columns: []string{"session", "hits", "topic"}})
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if len(decoded) != 2 || decoded[1]["session"] != "s2" || decoded[1]["hits"] != 2.0 {
+		t.Errorf("decoded = %v", decoded)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(strings.Split(buf.String(), "\n")[2]), `"session"`) {
+		t.Errorf("keys should follow the column order:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeList(&buf, rows, listOptions{format: "jsonl", columns: []string{"session"}})
+	if buf.String() != "{\"session\":\"s1\"}\n{\"session\":\"s2\"}\n" {
+		t.Errorf("jsonl = %q", buf.String())
+	}
+
+	buf.Reset()
+	writeList(&buf, rows, listOptions{format: "tsv", columns: []string{"session", "topic", "msgs"}})
+	want := "session\ttopic\tmsgs\ns1\tfix the login page\t1\ns2\tlogin bug\t3\n"
+	if buf.String() != want {
+		t.Errorf("tsv = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeList(&buf, rows, listOptions{format: "table", columns: []string{"project", "msgs"}})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "PROJECT") || !strings.HasSuffix(lines[2], "    3") {
+		t.Errorf("table = %q", buf.String())
+	}
+}
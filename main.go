@@ -4,12 +4,13 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -22,37 +23,74 @@ var version = "dev"
 
 // Message represents a conversation message
 type Message struct {
-	Role string `json:"role"`
-	Text string `json:"text"`
-	Ts   string `json:"ts"`
+	Role string    `json:"role"` // user, assistant, tool, or thinking
+	Text string    `json:"text"`
+	Ts   string    `json:"ts"`
+	Kind string    `json:"kind,omitempty"` // Empty for text, or tool_use / tool_result / thinking
+	Tool *ToolCall `json:"tool,omitempty"`
+	UUID string    `json:"uuid,omitempty"` // Line the message came from
+
+	Model     string `json:"model,omitempty"`     // Model that wrote an assistant message
+	Sidechain bool   `json:"sidechain,omitempty"` // Written by a sub-agent
 }
 
 // Conversation represents a parsed conversation
 type Conversation struct {
-	SessionID      string    `json:"session_id"`
-	Cwd            string    `json:"cwd"`
-	FirstTimestamp string    `json:"first_timestamp"`
-	LastTimestamp  string    `json:"last_timestamp"`
-	Messages       []Message `json:"messages"`
-	FilePath       string    `json:"file_path"` // Full path to the .jsonl file
+	SessionID      string         `json:"session_id"`
+	Cwd            string         `json:"cwd"`
+	FirstTimestamp string         `json:"first_timestamp"`
+	LastTimestamp  string         `json:"last_timestamp"`
+	Messages       []Message      `json:"messages"`
+	FilePath       string         `json:"file_path"`           // Full path to the .jsonl file
+	GitBranch      string         `json:"git_branch"`          // Most recent branch the session ran on
+	Summary        string         `json:"summary"`             // Latest generated summary, if any
+	Summaries      []summaryLine  `json:"-"`                   // Summary lines, until their leaves are known
+	ParentID       string         `json:"parent_id,omitempty"` // Session a sub-agent thread belongs to
+	Agents         []Conversation `json:"agents,omitempty"`    // Sub-agent threads, attached after scanning
+	Compactions    int            `json:"compactions"`         // Times the context was compacted
+	Links          []lineLink     `json:"-"`                   // Message tree, in file order
+	BranchCount    int            `json:"branch_count"`        // Active plus abandoned branches
+	Models         []string       `json:"models"`              // Models used, in order of first use
+	Version        string         `json:"version"`             // Most recent Claude Code version
+	Usage          tokenUsage     `json:"usage"`               // Tokens used, excluding sub-agents
+	Sidechain      bool           `json:"sidechain"`           // Lines are marked as sub-agent work
+	UsageLog       []usageRecord  `json:"-"`                   // Usage of each API response
+	Annotation     annotation     `json:"-"`                   // Star, tags and note from the ccs store
+}
+
+// summaryLine is a summary record. A file can hold summaries of other
+// sessions too; only those whose leaf is one of its own lines describe it.
+type summaryLine struct {
+	LeafUUID string
+	Text     string
 }
 
 // RawMessage represents the JSON structure in conversation files
 type RawMessage struct {
-	Type    string `json:"type"`
-	Cwd     string `json:"cwd"`
-	Message struct {
+	Type       string `json:"type"`
+	Cwd        string `json:"cwd"`
+	SessionID  string `json:"sessionId"`
+	UUID       string `json:"uuid"`
+	ParentUUID string `json:"parentUuid"`
+	// Compaction restarts the tree; the logical parent links across it
+	LogicalParentUUID string          `json:"logicalParentUuid"`
+	Subtype           string          `json:"subtype"`
+	CompactMetadata   compactMetadata `json:"compactMetadata"`
+	IsCompactSummary  bool            `json:"isCompactSummary"`
+	GitBranch         string          `json:"gitBranch"`
+	Version           string          `json:"version"`
+	IsSidechain       bool            `json:"isSidechain"`
+	Summary           string          `json:"summary"`  // type: summary
+	LeafUUID          string          `json:"leafUuid"` // Last line the summary covers
+	Message           struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Usage   tokenUsage      `json:"usage"`
 		Content json.RawMessage `json:"content"`
 	} `json:"message"`
 	Timestamp string `json:"timestamp"`
 }
 
-// TextContent for parsing content arrays
-type TextContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
-}
-
 // listItem holds display and search data for a conversation
 type listItem struct {
 	conv       Conversation
@@ -107,10 +145,28 @@ type model struct {
 	selected       *Conversation
 	quitting       bool
 	claudeFlags    []string
-	mouseInPreview bool // Track if mouse is in preview area
-	confirmDelete  bool   // Are we in delete confirmation mode?
-	deleteIndex    int    // Index of item to delete
-	errorMsg       string // Show deletion errors
+	mouseInPreview bool            // Track if mouse is in preview area
+	confirmDelete  bool            // Are we in delete confirmation mode?
+	deleteIndex    int             // Index of item to delete
+	permanent      bool            // Delete for good instead of moving to the trash
+	lastDeleted    []deletedItem   // Most recent deletion, for undo
+	marked         map[string]bool // Sessions marked for a bulk action
+	editing        editField       // Which annotation the edit input is changing, if any
+	editInput      textinput.Model // Replaces the search line while editing
+	errorMsg       string          // Show deletion errors
+	statusMsg      string          // Show the outcome of an action, e.g. an export
+	mode           searchMode
+	search         searchOptions // What searchText was built from
+	showThinking   bool          // Show thinking blocks in the preview
+	expandAgents   bool          // Show sub-agent threads in full in the preview
+	branch         int           // Branch of branchSession shown in the preview
+	branchSession  string        // Session the branch selection applies to
+	showStats      bool          // Show the token usage pane instead of the preview
+	config         config        // User configuration (prices)
+	redactor       *redactor     // Masks secrets in exports
+	redactPreview  bool          // Also mask secrets in the list and preview
+	match          matcher       // Compiled from the search box by updateFilter
+	queryErr       string        // Why the search box doesn't parse, if it doesn't
 }
 
 func initialModel(items []listItem, filterQuery string, claudeFlags []string) model {
@@ -125,23 +181,47 @@ func initialModel(items []listItem, filterQuery string, claudeFlags []string) mo
 		items:       items,
 		textInput:   ti,
 		claudeFlags: claudeFlags,
+		redactor:    &redactor{detectors: builtinDetectors},
 	}
 	m.updateFilter()
 	return m
 }
 
 func (m *model) updateFilter() {
-	query := m.textInput.Value()
-	if query == "" {
+	match, err := newMatcher(m.textInput.Value(), m.mode)
+	if err != nil {
+		// Keep showing the last valid results while the query is being typed
+		m.queryErr = fmt.Sprintf("Query error: %v", err)
+		if m.filtered == nil {
+			m.filtered = make([]listItem, len(m.items))
+			copy(m.filtered, m.items)
+		}
+		return
+	}
+	m.match = match
+	m.match.search = m.search
+	m.queryErr = ""
+
+	if m.match.empty() {
 		// Make a copy to avoid sharing backing array with m.items
 		m.filtered = make([]listItem, len(m.items))
 		copy(m.filtered, m.items)
+	} else if m.mode == modeFuzzy {
+		// Fuzzy matching, best matches first with a bonus for recent activity
+		now := time.Now()
+		var scored []scoredItem
+		for _, item := range m.items {
+			if score, ok := m.match.matchItem(item); ok {
+				score += recencyBonus(item.conv.LastTimestamp, now)
+				scored = append(scored, scoredItem{item, score})
+			}
+		}
+		m.filtered = rankItems(scored)
 	} else {
 		// Exact substring matching (case-insensitive)
-		queryLower := strings.ToLower(query)
 		m.filtered = make([]listItem, 0)
 		for _, item := range m.items {
-			if strings.Contains(strings.ToLower(item.searchText), queryLower) {
+			if _, ok := m.match.matchItem(item); ok {
 				m.filtered = append(m.filtered, item)
 			}
 		}
@@ -153,6 +233,15 @@ func (m *model) updateFilter() {
 	m.previewScroll = 0
 }
 
+// rebuildSearchText recomputes every item's searchText after the search
+// options change
+func (m *model) rebuildSearchText() {
+	for i := range m.items {
+		m.items[i].searchText = buildSearchText(m.items[i].conv, m.search)
+	}
+	m.updateFilter()
+}
+
 func (m model) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -212,11 +301,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil // Ignore all other keys
 		}
 
-		// Clear error message on any keypress in normal mode
-		if m.errorMsg != "" {
-			m.errorMsg = ""
+		// Handle annotation editing mode
+		if m.editing != editNone {
+			switch msg.String() {
+			case "enter":
+				m.finishEdit()
+			case "esc", "ctrl+c":
+				m.editing = editNone
+			default:
+				var cmd tea.Cmd
+				m.editInput, cmd = m.editInput.Update(msg)
+				return m, cmd
+			}
+			return m, nil
 		}
 
+		// Clear error and status messages on any keypress in normal mode
+		m.errorMsg, m.statusMsg = "", ""
+
 		switch msg.String() {
 		case "ctrl+c", "esc":
 			m.quitting = true
@@ -229,6 +331,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 
+		case "tab":
+			m.toggleMark()
+			return m, nil
+
+		case "alt+a":
+			m.markAll()
+			return m, nil
+
 		case "ctrl+d":
 			if len(m.filtered) > 0 {
 				m.confirmDelete = true
@@ -262,6 +372,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textInput.SetValue("")
 			m.updateFilter()
 			return m, nil
+
+		case "ctrl+r":
+			m.mode = m.mode.next()
+			m.updateFilter()
+			return m, nil
+
+		case "ctrl+s":
+			m.search.Scope = m.search.Scope.next()
+			m.rebuildSearchText()
+			return m, nil
+
+		case "ctrl+t":
+			m.showThinking = !m.showThinking
+			m.previewScroll = 0
+			return m, nil
+
+		case "alt+n":
+			if m.cursor < len(m.filtered) {
+				conv := m.filtered[m.cursor].conv
+				if conv.SessionID != m.branchSession {
+					m.branchSession, m.branch = conv.SessionID, 0
+				}
+				m.branch = (m.branch + 1) % max(1, conv.BranchCount)
+				m.previewScroll = 0
+			}
+			return m, nil
+
+		case "ctrl+g":
+			m.showStats = !m.showStats
+			m.previewScroll = 0
+			return m, nil
+
+		case "ctrl+o":
+			m.expandAgents = !m.expandAgents
+			m.previewScroll = 0
+			return m, nil
+
+		case "ctrl+z":
+			m.undoDelete()
+			return m, nil
+
+		case "alt+s":
+			m.toggleStar()
+			return m, nil
+
+		case "ctrl+l":
+			m.startEdit(editTags)
+			return m, nil
+
+		case "ctrl+x":
+			m.startEdit(editNote)
+			return m, nil
+
+		case "alt+e":
+			if len(m.filtered) > 0 {
+				m.exportSelected(exportFormats["markdown"])
+			}
+			return m, nil
 		}
 	}
 
@@ -282,8 +450,8 @@ func (m model) View() string {
 
 	var b strings.Builder
 
-	// Table width: 2 + 16 + 2 + 22 + 2 + 40 + 2 + 5 + 2 + 4 = 97
-	tableWidth := 97
+	// Table width: 2 + 2 + 16 + 2 + 22 + 2 + 40 + 2 + 5 + 2 + 3 + 2 + 4 = 104
+	tableWidth := 104
 
 	// Title line with help right-aligned
 	title := fmt.Sprintf("ccs · claude code search · %s", version)
@@ -299,13 +467,33 @@ func (m model) View() string {
 	var sections []string
 	var inputSection string
 	if m.confirmDelete {
-		topic := getTopic(m.filtered[m.deleteIndex].conv)
+		targets := m.deleteTargets()
+		what := fmt.Sprintf("%d marked conversations", len(targets))
+		if len(targets) == 1 {
+			what = fmt.Sprintf("conversation \"%s\"", truncate(getTopic(targets[0].conv), 50))
+		}
+		prompt := "Delete " + what + "? [y/N]"
+		if m.permanent {
+			prompt = "Permanently delete " + what + "? This can't be undone [y/N]"
+		}
 		inputSection = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("196")). // Red
-			Render(fmt.Sprintf("Delete conversation \"%s\"? [y/N]", truncate(topic, 50)))
+			Render(prompt)
+		sections = append(sections, "  "+inputSection)
+	} else if m.editing != editNone {
+		label := "Tags"
+		if m.editing == editNote {
+			label = "Note"
+		} else if targets := m.actionTargets(); len(targets) > 1 {
+			label = fmt.Sprintf("Tags of %d marked", len(targets))
+		}
+		inputSection = "\033[1;35m" + label + ":\033[0m " + m.editInput.View() + "  \033[90mEnter to save, Esc to cancel\033[0m"
 		sections = append(sections, "  "+inputSection)
 	} else {
-		count := fmt.Sprintf("(%d/%d)", len(m.filtered), len(m.items))
+		count := fmt.Sprintf("%s · %s (%d/%d)", m.mode, m.search.Scope, len(m.filtered), len(m.items))
+		if n := len(m.markedItems()); n > 0 {
+			count = fmt.Sprintf("%d marked · %s", n, count)
+		}
 		searchPadding := tableWidth - 2 - 2 - 40 - len(count) - 1 // 2 for indent, 2 for "> ", 40 for textInput, -1 to shift left
 		if searchPadding < 1 {
 			searchPadding = 1
@@ -315,9 +503,16 @@ func (m model) View() string {
 	}
 
 	// Show error message if set
-	if m.errorMsg != "" {
+	errorMsg := m.errorMsg
+	if errorMsg == "" {
+		errorMsg = m.queryErr
+	}
+	if errorMsg != "" {
 		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-		sections = append(sections, "  "+errorStyle.Render(m.errorMsg))
+		sections = append(sections, "  "+errorStyle.Render(errorMsg))
+	} else if m.statusMsg != "" {
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")) // Green
+		sections = append(sections, "  "+statusStyle.Render(m.statusMsg))
 	}
 
 	b.WriteString(strings.Join(sections, "\n"))
@@ -331,7 +526,7 @@ func (m model) View() string {
 	previewHeight := m.height - listHeight - 6 // 6 for title + search + blank + header + borders
 
 	// Column headers
-	b.WriteString(fmt.Sprintf("  \033[90m%-16s  %-22s  %-40s  %5s  %4s\033[0m\n", "DATE", "PROJECT", "TOPIC", "MSGS", "HITS"))
+	b.WriteString(fmt.Sprintf("    \033[90m%-16s  %-22s  %-40s  %5s  %3s  %4s\033[0m\n", "DATE", "PROJECT", "TOPIC", "MSGS", "BR", "HITS"))
 	b.WriteString(strings.Repeat("─", m.width))
 	b.WriteString("\n")
 
@@ -365,7 +560,9 @@ func (m model) View() string {
 	b.WriteString(strings.Repeat("─", m.width))
 	b.WriteString("\n")
 
-	if len(m.filtered) > 0 {
+	if m.showStats {
+		b.WriteString(m.renderStats(previewHeight))
+	} else if len(m.filtered) > 0 {
 		preview := m.renderPreview(m.filtered[m.cursor], previewHeight)
 		b.WriteString(preview)
 	}
@@ -384,57 +581,121 @@ func (m model) formatListItem(item listItem, selected bool) string {
 		project = project[:19] + "..."
 	}
 
-	// Use first user message as topic
-	topic := ""
-	for _, msg := range item.conv.Messages {
-		if msg.Role == "user" {
-			topic = truncate(msg.Text, 40)
-			break
-		}
+	topic := m.previewText(getTopic(item.conv))
+	if label := item.conv.Annotation.label(); label != "" {
+		topic = label + " " + topic
 	}
+	topic = truncate(topic, 40)
 
-	// Message count
-	msgs := len(item.conv.Messages)
+	// Message count (tool calls and results aren't messages)
+	msgs := item.conv.chatMessageCount()
 
-	// Count messages containing the query
-	query := m.textInput.Value()
-	hits := 0
-	if query != "" {
-		queryLower := strings.ToLower(query)
-		for _, msg := range item.conv.Messages {
-			if strings.Contains(strings.ToLower(msg.Text), queryLower) {
-				hits++
-			}
-		}
+	// Count messages matching the query
+	hits := m.match.countHits(item.conv)
+
+	// Branch count, left blank for the usual single branch
+	branches := ""
+	if item.conv.BranchCount > 1 {
+		branches = strconv.Itoa(item.conv.BranchCount)
 	}
 
-	// Format: date | project | topic | msgs | hits (aligned columns)
+	// Rows marked for a bulk action
+	mark := " "
+	if m.marked[item.conv.SessionID] {
+		mark = "●"
+	}
+
+	// Format: mark | date | project | topic | msgs | branches | hits (aligned columns)
 	if selected {
-		return fmt.Sprintf("%-16s  %-22s  %-40s  %5d  %4d", ts, project, topic, msgs, hits)
+		return fmt.Sprintf("%s %-16s  %-22s  %-40s  %5d  %3s  %4d", mark, ts, project, topic, msgs, branches, hits)
 	}
-	return fmt.Sprintf("\033[90m%-16s\033[0m  \033[1;33m%-22s\033[0m  %-40s  %5d  \033[36m%4d\033[0m",
-		ts, project, topic, msgs, hits)
+	return fmt.Sprintf("\033[1;32m%s\033[0m \033[90m%-16s\033[0m  \033[1;33m%-22s\033[0m  %-40s  %5d  \033[35m%3s\033[0m  \033[36m%4d\033[0m",
+		mark, ts, project, topic, msgs, branches, hits)
 }
 
 func (m model) renderPreview(item listItem, height int) string {
-	query := m.textInput.Value()
 	conv := item.conv
 
 	// Fixed header (always visible)
 	var header []string
-	header = append(header, "\033[1;33mProject:\033[0m "+highlight(conv.Cwd, query))
-	header = append(header, "\033[1;33mSession:\033[0m "+highlight(conv.SessionID, query))
+	header = append(header, "\033[1;33mProject:\033[0m "+m.match.highlight(conv.Cwd))
+	header = append(header, "\033[1;33mSession:\033[0m "+m.match.highlight(conv.SessionID))
+	if meta := metadataLine(conv); meta != "" {
+		header = append(header, meta)
+	}
+	if line := annotationLine(conv.Annotation); line != "" {
+		header = append(header, line)
+	}
+
+	// Show one branch of the message tree, the active one unless browsing
+	if branches := conv.branches(); len(branches) > 1 {
+		selected := m.selectedBranch(conv, len(branches))
+		shown := make(map[int]bool, len(branches[selected]))
+		messages := make([]Message, 0, len(branches[selected]))
+		for _, i := range branches[selected] {
+			shown[i] = true
+			messages = append(messages, conv.Messages[i])
+		}
+		state := "active"
+		if selected > 0 {
+			state = "abandoned"
+		}
+		line := fmt.Sprintf("\033[1;33mBranch:\033[0m %d/%d (%s, Alt+N for next)", selected+1, len(branches), state)
+
+		// Point out matches that are only on branches not shown
+		elsewhere := 0
+		if !m.match.empty() {
+			for i, msg := range conv.Messages {
+				if !shown[i] && m.match.matchMessage(msg) {
+					elsewhere++
+				}
+			}
+		}
+		if elsewhere > 0 {
+			line += fmt.Sprintf(" \033[36m%d hits on other branches\033[0m", elsewhere)
+		}
+		header = append(header, line)
+		conv.Messages = messages
+	}
 	header = append(header, "")
 
+	if !m.showThinking {
+		conv.Messages = withoutThinking(conv.Messages)
+	}
+
 	// Build message lines (scrollable)
+	msgLines := m.renderMessages(conv.Messages)
+	if len(conv.Agents) > 0 {
+		msgLines = append(msgLines, "")
+		msgLines = append(msgLines, m.renderAgents(conv.Agents)...)
+	}
+
+	// Apply scroll to messages only (header stays fixed)
+	msgHeight := height - len(header)
+	if msgHeight < 1 {
+		msgHeight = 1
+	}
+	if m.previewScroll >= len(msgLines) {
+		m.previewScroll = max(0, len(msgLines)-1)
+	}
+	end := min(m.previewScroll+msgHeight, len(msgLines))
+	visibleMsgLines := msgLines[m.previewScroll:end]
+
+	// Combine header + scrolled messages
+	allLines := append(header, visibleMsgLines...)
+	return strings.Join(allLines, "\n")
+}
+
+// renderMessages renders a thread for the preview: the first and last two
+// messages plus every match with its neighbours, with gaps summarised
+func (m model) renderMessages(messages []Message) []string {
 	var msgLines []string
 
-	// Find messages containing the query
-	queryLower := strings.ToLower(query)
+	// Find messages matching the query
 	matchSet := make(map[int]bool)
-	if query != "" {
-		for i, msg := range conv.Messages {
-			if strings.Contains(strings.ToLower(msg.Text), queryLower) {
+	if !m.match.empty() {
+		for i, msg := range messages {
+			if m.match.matchMessage(msg) {
 				matchSet[i] = true
 			}
 		}
@@ -444,29 +705,36 @@ func (m model) renderPreview(item listItem, height int) string {
 	showSet := make(map[int]bool)
 
 	// Always show first 2 and last 2 messages
-	for i := 0; i < 2 && i < len(conv.Messages); i++ {
+	for i := 0; i < 2 && i < len(messages); i++ {
 		showSet[i] = true
 	}
-	for i := len(conv.Messages) - 2; i < len(conv.Messages); i++ {
+	for i := len(messages) - 2; i < len(messages); i++ {
 		if i >= 0 {
 			showSet[i] = true
 		}
 	}
 
+	// Compaction boundaries act as separators, so always show them
+	for i, msg := range messages {
+		if msg.Kind == kindCompaction {
+			showSet[i] = true
+		}
+	}
+
 	// Add matches with context
 	for idx := range matchSet {
 		if idx > 0 {
 			showSet[idx-1] = true
 		}
 		showSet[idx] = true
-		if idx < len(conv.Messages)-1 {
+		if idx < len(messages)-1 {
 			showSet[idx+1] = true
 		}
 	}
 
 	// Display messages with gaps
 	lastShown := -1
-	for i := 0; i < len(conv.Messages); i++ {
+	for i := 0; i < len(messages); i++ {
 		if !showSet[i] {
 			continue
 		}
@@ -480,55 +748,64 @@ func (m model) renderPreview(item listItem, height int) string {
 			msgLines = append(msgLines, "")
 		}
 
-		msg := conv.Messages[i]
+		msg := messages[i]
 		ts := formatTimestamp(msg.Ts)
-		var prefix string
+		if msg.Kind == kindCompaction {
+			msgLines = append(msgLines, fmt.Sprintf("\033[33m──── %s %s ────\033[0m", ts, msg.Text)) // Yellow
+			msgLines = append(msgLines, "")
+			lastShown = i
+			continue
+		}
+		label, color := messageLabel(msg)
+		prefix := fmt.Sprintf("\033[%sm    %s %s:\033[0m", color, ts, label)
 		if matchSet[i] {
-			if msg.Role == "user" {
-				prefix = fmt.Sprintf("\033[1;32m>>> %s User:\033[0m", ts) // Bold green
-			} else {
-				prefix = fmt.Sprintf("\033[1;34m>>> %s Claude:\033[0m", ts) // Bold blue
-			}
-		} else {
-			if msg.Role == "user" {
-				prefix = fmt.Sprintf("\033[32m    %s User:\033[0m", ts) // Green
-			} else {
-				prefix = fmt.Sprintf("\033[34m    %s Claude:\033[0m", ts) // Blue
-			}
+			prefix = fmt.Sprintf("\033[1;%sm>>> %s %s:\033[0m", color, ts, label) // Bold
 		}
 
 		msgLines = append(msgLines, prefix)
 		text := msg.Text
+		if msg.Kind == kindToolUse {
+			text = toolSummary(msg.Tool)
+		}
+		text = m.previewText(text)
 		if len(text) > 500 {
 			text = text[:500] + "... (truncated)"
 		}
 		for _, line := range strings.Split(text, "\n") {
-			msgLines = append(msgLines, "    "+highlight(line, query))
+			msgLines = append(msgLines, "    "+m.match.highlight(line))
 		}
 		msgLines = append(msgLines, "")
 
 		lastShown = i
 	}
 
-	if lastShown < len(conv.Messages)-1 {
-		remaining := len(conv.Messages) - lastShown - 1
+	if lastShown < len(messages)-1 {
+		remaining := len(messages) - lastShown - 1
 		msgLines = append(msgLines, fmt.Sprintf("\033[90m    ... %d more messages\033[0m", remaining))
 	}
 
-	// Apply scroll to messages only (header stays fixed)
-	msgHeight := height - len(header)
-	if msgHeight < 1 {
-		msgHeight = 1
-	}
-	if m.previewScroll >= len(msgLines) {
-		m.previewScroll = max(0, len(msgLines)-1)
-	}
-	end := min(m.previewScroll+msgHeight, len(msgLines))
-	visibleMsgLines := msgLines[m.previewScroll:end]
+	return msgLines
+}
 
-	// Combine header + scrolled messages
-	allLines := append(header, visibleMsgLines...)
-	return strings.Join(allLines, "\n")
+// messageLabel names the sender of a message for transcript headings,
+// with the ANSI colour code it is shown in
+func messageLabel(msg Message) (label, color string) {
+	switch {
+	case msg.Kind == kindCompactSummary:
+		return "Compaction summary", "90" // Grey
+	case msg.Kind == kindThinking:
+		return "Thinking", "90" // Grey
+	case msg.Kind == kindToolUse:
+		return "Tool " + msg.Tool.Name, "35" // Magenta
+	case msg.Kind == kindToolResult && msg.Tool.IsError:
+		return "Error " + msg.Tool.Name, "31" // Red
+	case msg.Kind == kindToolResult:
+		return "Result " + msg.Tool.Name, "35" // Magenta
+	case msg.Role == "user":
+		return "User", "32" // Green
+	default:
+		return "Claude", "34" // Blue
+	}
 }
 
 func highlight(text, query string) string {
@@ -577,43 +854,16 @@ var getProjectsDir = func() string {
 }
 
 func extractText(content json.RawMessage) string {
-	if len(content) == 0 {
-		return ""
-	}
-
-	var str string
-	if err := json.Unmarshal(content, &str); err == nil {
-		return str
-	}
-
-	var arr []TextContent
-	if err := json.Unmarshal(content, &arr); err == nil {
-		var parts []string
-		for _, item := range arr {
-			if item.Type == "text" && item.Text != "" {
-				parts = append(parts, item.Text)
-			}
-		}
-		return strings.Join(parts, " ")
-	}
-
-	return ""
+	text, _ := decodeContent(content)
+	return text
 }
 
-
-
-
-
 func parseConversationFile(path string, cutoff time.Time, maxSize int64) (*Conversation, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.HasPrefix(info.Name(), "agent-") {
-		return nil, nil
-	}
-
 	// Skip files larger than maxSize (0 means no limit)
 	if maxSize > 0 && info.Size() > maxSize {
 		return nil, nil
@@ -624,124 +874,249 @@ func parseConversationFile(path string, cutoff time.Time, maxSize int64) (*Conve
 		return nil, nil
 	}
 
-	sessionID := strings.TrimSuffix(info.Name(), ".jsonl")
-	conv := &Conversation{
-		SessionID: sessionID,
-		FilePath:  path,
+	conv, _, err := resumeConversationFile(path, nil, parseState{})
+	return conv, err
+}
+
+// parseState records how far a file has been parsed, so lines appended
+// later can be picked up without re-reading the whole file
+type parseState struct {
+	Offset  int64  // Bytes consumed, always at the end of a complete line
+	HeadLen int    // Number of leading bytes covered by HeadSum
+	HeadSum uint64 // Checksum used to detect a rewritten file
+}
+
+// headBytes is how much of the start of a file is checksummed
+const headBytes = 4096
+
+// fileHead checksums the first n bytes of file
+func fileHead(file *os.File, n int64) (int, uint64, error) {
+	buf := make([]byte, n)
+	read, err := file.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return 0, 0, err
 	}
+	h := fnv.New64a()
+	h.Write(buf[:read])
+	return read, h.Sum64(), nil
+}
 
+// resumeConversationFile parses path starting where a previous parse of the
+// same file stopped, merging new messages into a copy of prev. It falls
+// back to a full parse when there is no previous result, the file shrank,
+// or its header no longer matches.
+func resumeConversationFile(path string, prev *Conversation, state parseState) (*Conversation, parseState, error) {
+	if isArchived(path) {
+		conv, err := parseArchivedFile(path)
+		return conv, parseState{}, err
+	}
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, parseState{}, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
-
-	for scanner.Scan() {
-		lineBytes := scanner.Bytes()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, parseState{}, err
+	}
 
-		var raw RawMessage
-		if err := json.Unmarshal(lineBytes, &raw); err != nil {
-			continue
+	var conv *Conversation
+	if prev != nil && state.Offset > 0 && info.Size() >= state.Offset {
+		if n, sum, err := fileHead(file, int64(state.HeadLen)); err == nil && n == state.HeadLen && sum == state.HeadSum {
+			conv = prev.clone()
+			// Finalised fields are recomputed once the new lines are in
+			if conv.Cwd == "unknown" {
+				conv.Cwd = ""
+			}
+		}
+	}
+	if conv == nil {
+		conv = &Conversation{
+			SessionID: strings.TrimSuffix(info.Name(), ".jsonl"),
+			FilePath:  path,
+		}
+		state = parseState{}
+	}
+	if state.Offset == 0 {
+		state.HeadLen, state.HeadSum, err = fileHead(file, min(info.Size(), headBytes))
+		if err != nil {
+			return nil, parseState{}, err
 		}
+	}
 
-		if raw.Type == "user" {
-			if conv.Cwd == "" {
-				conv.Cwd = raw.Cwd
-			}
-			text := extractText(raw.Message.Content)
-			if strings.TrimSpace(text) != "" {
-				if conv.FirstTimestamp == "" {
-					conv.FirstTimestamp = raw.Timestamp
-				}
-				conv.Messages = append(conv.Messages, Message{
-					Role: "user",
-					Text: text,
-					Ts:   raw.Timestamp,
-				})
-			}
-		} else if raw.Type == "assistant" {
-			text := extractText(raw.Message.Content)
-			if strings.TrimSpace(text) != "" {
-				conv.Messages = append(conv.Messages, Message{
-					Role: "assistant",
-					Text: text,
-					Ts:   raw.Timestamp,
-				})
+	if _, err := file.Seek(state.Offset, io.SeekStart); err != nil {
+		return nil, parseState{}, err
+	}
+
+	reader := bufio.NewReaderSize(file, 1024*1024)
+	for {
+		lineBytes, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A trailing line without newline may still be being written;
+			// only consume it once it is complete JSON
+			if len(lineBytes) > 0 && json.Valid(lineBytes) {
+				conv.parseLine(lineBytes)
+				state.Offset += int64(len(lineBytes))
 			}
+			break
+		}
+		if err != nil {
+			return nil, parseState{}, err
 		}
+		conv.parseLine(lineBytes)
+		state.Offset += int64(len(lineBytes))
 	}
 
+	if !conv.finish() {
+		return nil, state, nil
+	}
+	return conv, state, nil
+}
+
+// finish fills in the fields derived from all of a parsed file's lines. It
+// reports false when the file held no messages.
+func (conv *Conversation) finish() bool {
 	if len(conv.Messages) == 0 {
-		return nil, nil
+		return false
 	}
 
 	conv.LastTimestamp = conv.Messages[len(conv.Messages)-1].Ts
+	conv.BranchCount = len(conv.branches())
+	conv.Summary = conv.ownSummary()
 
 	if conv.Cwd == "" {
 		conv.Cwd = "unknown"
 	}
-
-	return conv, nil
+	return true
 }
 
-func getConversations(cutoff time.Time, maxSize int64) ([]Conversation, error) {
-	projectsDir := getProjectsDir()
+// ownSummary returns the latest summary whose leaf is a line of this
+// conversation. Summary lines come before the lines they cover, so this
+// waits until the whole file is read.
+func (conv *Conversation) ownSummary() string {
+	uuids := make(map[string]bool, len(conv.Links))
+	for _, link := range conv.Links {
+		uuids[link.UUID] = true
+	}
+	for i := len(conv.Summaries) - 1; i >= 0; i-- {
+		if uuids[conv.Summaries[i].LeafUUID] {
+			return conv.Summaries[i].Text
+		}
+	}
+	return ""
+}
 
-	var files []string
-	err := filepath.Walk(projectsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
+// parseLine adds the message on one line of a conversation file, if any
+func (conv *Conversation) parseLine(lineBytes []byte) {
+	var raw RawMessage
+	if err := json.Unmarshal(lineBytes, &raw); err != nil {
+		return
+	}
+	if raw.UUID != "" {
+		parent := raw.ParentUUID
+		if parent == "" {
+			parent = raw.LogicalParentUUID
 		}
-		if !info.IsDir() && strings.HasSuffix(path, ".jsonl") && !strings.HasPrefix(info.Name(), "agent-") {
-			files = append(files, path)
+		conv.Links = append(conv.Links, lineLink{UUID: raw.UUID, Parent: parent})
+	}
+	defer conv.annotate(len(conv.Messages), &raw)
+
+	if raw.GitBranch != "" {
+		conv.GitBranch = raw.GitBranch
+	}
+	if raw.Version != "" {
+		conv.Version = raw.Version
+	}
+	if raw.IsSidechain {
+		conv.Sidechain = true
+	}
+	if conv.ParentID == "" && raw.SessionID != "" && isAgentFile(conv.FilePath) {
+		conv.ParentID = raw.SessionID
+	}
+	if raw.Type == "summary" {
+		if s := strings.TrimSpace(raw.Summary); s != "" {
+			conv.Summaries = append(conv.Summaries, summaryLine{LeafUUID: raw.LeafUUID, Text: s})
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
+		return
+	}
+	if raw.Type == "system" && raw.Subtype == "compact_boundary" {
+		conv.Compactions++
+		conv.Messages = append(conv.Messages, compactionMessage(raw.CompactMetadata, raw.Timestamp))
+		return
 	}
 
-	// Worker pool to limit concurrent file operations
-	const numWorkers = 8
-	jobs := make(chan string, len(files))
-	results := make(chan *Conversation, len(files))
-
-	var wg sync.WaitGroup
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for path := range jobs {
-				conv, err := parseConversationFile(path, cutoff, maxSize)
-				if err == nil && conv != nil {
-					results <- conv
-				}
+	if raw.Type != "user" && raw.Type != "assistant" {
+		return
+	}
+	text, blocks := decodeContent(raw.Message.Content)
+
+	if raw.Type == "user" {
+		if conv.Cwd == "" {
+			conv.Cwd = raw.Cwd
+		}
+		if raw.IsCompactSummary {
+			conv.addCompactSummary(text, raw.Timestamp)
+		} else if strings.TrimSpace(text) != "" {
+			if conv.FirstTimestamp == "" {
+				conv.FirstTimestamp = raw.Timestamp
 			}
-		}()
+			conv.Messages = append(conv.Messages, Message{
+				Role: "user",
+				Text: text,
+				Ts:   raw.Timestamp,
+			})
+		}
+	} else if raw.Type == "assistant" {
+		conv.addModel(raw.Message.Model)
+		conv.addUsage(raw.Message.ID, raw.Message.Model, raw.Timestamp, raw.Message.Usage)
+		conv.Messages = append(conv.Messages, thinkingMessages(blocks, raw.Timestamp)...)
+		if strings.TrimSpace(text) != "" {
+			conv.Messages = append(conv.Messages, Message{
+				Role: "assistant",
+				Text: text,
+				Ts:   raw.Timestamp,
+			})
+		}
 	}
+	conv.Messages = append(conv.Messages, conv.toolMessages(blocks, raw.Timestamp)...)
+}
 
-	for _, file := range files {
-		jobs <- file
+// annotate copies line-level metadata onto the messages parsed from the
+// line, which start at index start
+func (conv *Conversation) annotate(start int, raw *RawMessage) {
+	for i := start; i < len(conv.Messages); i++ {
+		msg := &conv.Messages[i]
+		msg.UUID = raw.UUID
+		msg.Sidechain = raw.IsSidechain
+		if raw.Type == "assistant" {
+			msg.Model = raw.Message.Model
+		}
 	}
-	close(jobs)
+}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+// clone returns a copy of conv that can be appended to without touching
+// the original's slices
+func (conv *Conversation) clone() *Conversation {
+	c := *conv
+	c.Messages = append([]Message(nil), conv.Messages...)
+	c.Links = append([]lineLink(nil), conv.Links...)
+	c.Summaries = append([]summaryLine(nil), conv.Summaries...)
+	c.Models = append([]string(nil), conv.Models...)
+	c.UsageLog = append([]usageRecord(nil), conv.UsageLog...)
+	return &c
+}
 
-	var conversations []Conversation
-	for conv := range results {
-		conversations = append(conversations, *conv)
+func getConversations(cutoff time.Time, maxSize int64) ([]Conversation, error) {
+	entries, err := scanConversations(nil, cutoff, maxSize)
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(conversations, func(i, j int) bool {
-		return conversations[i].LastTimestamp > conversations[j].LastTimestamp
-	})
-
+	conversations := make([]Conversation, 0, len(entries))
+	for _, entry := range entries {
+		conversations = append(conversations, *entry.Conv)
+	}
 	return conversations, nil
 }
 
@@ -767,42 +1142,67 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// getTopic returns the first user message or session ID
+// getTopic returns the conversation's summary, falling back to the first
+// user message or session ID
 func getTopic(conv Conversation) string {
+	if conv.Summary != "" {
+		return conv.Summary
+	}
 	for _, msg := range conv.Messages {
-		if msg.Role == "user" {
+		if msg.Role == "user" && msg.Kind == "" {
 			return msg.Text
 		}
 	}
 	return conv.SessionID
 }
 
-// deleteConversation removes the selected conversation from disk and UI
+// deleteConversation moves the conversations being deleted (the marked
+// ones, else the selected one) to the trash, or with --permanent-delete
+// removes them from disk, and drops them from the UI. Failures are reported
+// without stopping the others.
 func (m *model) deleteConversation() {
-	if m.deleteIndex >= len(m.filtered) {
-		return
-	}
-
-	conv := m.filtered[m.deleteIndex].conv
-
-	// Delete the file (ignore if already deleted)
-	if err := os.Remove(conv.FilePath); err != nil && !os.IsNotExist(err) {
-		m.errorMsg = fmt.Sprintf("Delete failed: %v", err)
-		m.confirmDelete = false
+	targets := m.deleteTargets()
+	if len(targets) == 0 {
 		return
 	}
 
-	// Remove from filtered slice
-	m.filtered = append(m.filtered[:m.deleteIndex], m.filtered[m.deleteIndex+1:]...)
-
-	// Remove from items slice (find by SessionID)
-	for i, item := range m.items {
-		if item.conv.SessionID == conv.SessionID {
-			m.items = append(m.items[:i], m.items[i+1:]...)
-			break
+	var deleted []deletedItem
+	var failures []string
+	gone := make(map[string]bool)
+	for _, item := range targets {
+		conv := item.conv
+		if m.permanent {
+			// Delete the file (ignore if already deleted)
+			if err := os.Remove(conv.FilePath); err != nil && !os.IsNotExist(err) {
+				failures = append(failures, err.Error())
+				continue
+			}
+			// Its sub-agent transcripts would otherwise be orphaned
+			for _, agent := range conv.Agents {
+				os.Remove(agent.FilePath)
+			}
+		} else {
+			entry, err := trashConversation(conv)
+			if err != nil && !os.IsNotExist(err) {
+				failures = append(failures, err.Error())
+				continue
+			}
+			// A file already gone has nothing to undo, but its row goes
+			if err == nil {
+				deleted = append(deleted, deletedItem{entry: entry, item: item})
+			}
 		}
+		gone[conv.SessionID] = true
+		delete(m.marked, conv.SessionID)
+	}
+	if len(deleted) > 0 {
+		m.lastDeleted = deleted
 	}
 
+	// Remove from the filtered and items slices (by SessionID)
+	m.filtered = withoutSessions(m.filtered, gone)
+	m.items = withoutSessions(m.items, gone)
+
 	// Adjust cursor
 	if len(m.filtered) == 0 {
 		m.cursor = 0
@@ -813,30 +1213,43 @@ func (m *model) deleteConversation() {
 
 	// Exit confirmation mode
 	m.confirmDelete = false
-	m.errorMsg = ""
+	m.errorMsg = bulkFailures("Delete", len(targets), failures)
+	if len(deleted) > 0 {
+		m.statusMsg = "Moved to trash, Ctrl+Z to undo"
+		if len(deleted) > 1 {
+			m.statusMsg = fmt.Sprintf("Moved %d conversations to trash, Ctrl+Z to undo", len(deleted))
+		}
+	}
+}
+
+// buildSearchText joins all searchable content of a conversation
+func buildSearchText(conv Conversation, opts searchOptions) string {
+	var searchParts []string
+	searchParts = append(searchParts, conv.SessionID)
+	searchParts = append(searchParts, conv.Cwd)
+	searchParts = append(searchParts, conv.Summary)
+	searchParts = append(searchParts, conv.GitBranch, conv.Version)
+	searchParts = append(searchParts, conv.Models...)
+	searchParts = append(searchParts, formatTimestamp(conv.FirstTimestamp))
+	searchParts = append(searchParts, formatTimestamp(conv.LastTimestamp))
+
+	for _, msg := range conv.allMessages() {
+		if opts.includes(msg) {
+			searchParts = append(searchParts, msg.Text)
+		}
+	}
+
+	return strings.Join(searchParts, " ")
 }
 
 // buildItems creates list items from conversations
-func buildItems(conversations []Conversation) []listItem {
+func buildItems(conversations []Conversation, opts searchOptions) []listItem {
 	items := make([]listItem, 0, len(conversations))
 
 	for _, conv := range conversations {
-		// Build search text from all content
-		var searchParts []string
-		searchParts = append(searchParts, conv.SessionID)
-		searchParts = append(searchParts, conv.Cwd)
-		searchParts = append(searchParts, formatTimestamp(conv.FirstTimestamp))
-		searchParts = append(searchParts, formatTimestamp(conv.LastTimestamp))
-
-		for _, msg := range conv.Messages {
-			if msg.Role == "user" {
-				searchParts = append(searchParts, msg.Text)
-			}
-		}
-
 		items = append(items, listItem{
 			conv:       conv,
-			searchText: strings.Join(searchParts, " "),
+			searchText: buildSearchText(conv, opts),
 		})
 	}
 
@@ -849,34 +1262,111 @@ func printHelp() {
 Search and resume Claude Code conversations.
 
 Usage: ccs [filter] [-- claude-flags...]
+       ccs <command> [flags] [filter]
 
 Arguments:
   filter           Initial search query (optional)
   -- claude-flags  Flags to pass to 'claude --resume' (after --)
 
+Commands:
+  export SESSION   Write a conversation as a Markdown document or HTML page
+                   --format=markdown|html, --output=FILE (default: stdout),
+                   --thinking
+  list             Print the matching conversations instead of opening the TUI
+                   --format=table|tsv|json|jsonl, --columns=date,project,topic,
+                   msgs,hits,session,file, --sort=date|project|msgs|hits|score,
+                   --limit=N, --mode=exact|fuzzy|regex
+  show SESSION     Print a conversation in full (session ID, unique prefix or file)
+                   --tools, --thinking, --grep=QUERY, --context=N (default: 2)
+                   Secrets are redacted in show and export unless --no-redact
+  scan-secrets     List the sessions containing API keys, tokens and other secrets
+  prune            Move sessions meeting every rule to the trash: --older-than=90d,
+                   --fewer-than=N messages, --missing-cwd, a query; --dry-run to preview
+  archive          Compress sessions into ~/.local/share/ccs/archive, still searchable
+                   with --all and restored on resume: --older-than=90d, --dry-run
+  trash list       List conversations deleted with Ctrl+D or prune
+  trash restore S  Move a deleted conversation back (session ID or prefix)
+  trash purge      Delete trashed conversations for good: --older-than=30d or --all
+  stats            Token usage and estimated cost of the matching conversations
+                   --by=project|day|week|model (comma-separated, default: project)
+                   Prices come from ~/.config/ccs/config.json, with defaults
+  activity         Calendar heatmap and weekly timeline of sessions and messages
+                   --since=YYYY-MM-DD (default: a year ago), --project=NAME,
+                   --by=messages|sessions, --hours for an hour-of-day histogram
+
 Flags:
   -h, --help       Show this help message
   -v, --version    Show version
   --max-age=N      Only search last N days (default: 60, 0 = no limit)
   --max-size=N     Max file size in MB (default: 1024, 0 = no limit)
   --all            Include everything (same as --max-age=0 --max-size=0)
-  --dump [query]   Debug: print all search items (with optional highlighting)
+  --rebuild-index  Discard the search index cache and re-parse every file
+  --scope=S        Search user, assistant or both messages (default: both)
+  --include-thinking  Also search Claude's thinking blocks
+  --permanent-delete  Make Ctrl+D delete for good instead of moving to the trash
+  --redact         Mask secrets in the list and preview (always on in exports)
+  --dump [query]   Debug: print all search text (use ccs list for scripts)
+
+Search syntax:
+  foo bar          Conversations containing both words
+  "foo bar"        Exact phrase (also in fuzzy mode)
+  /fo+\sbar/       Regular expression (case-insensitive, use \s for spaces)
+  -foo, NOT foo    Exclude conversations containing foo
+  foo OR bar       Either word; group with parentheses
+  project:NAME     Project directory name contains NAME (cwd:PATH for full path)
+  session:ID       Session ID contains ID
+  branch:NAME      Git branch contains NAME
+  role:user        Match words only in user (or assistant, tool, thinking) messages
+  tool:NAME        Used tool NAME; words then match only that tool's calls and output
+  file:PATH        A tool read or edited a file whose path contains PATH
+  after:DATE       Active on or after DATE (YYYY-MM-DD)
+  before:DATE      Started before DATE
+  date:DATE        Active on DATE
+  msgs:>N          Message count (N, >N, >=N, <N, <=N)
+  model:NAME       Used a model whose name contains NAME
+  version:V        Last ran on a Claude Code version starting with V
+  tokens:>N        Total tokens, with k/M suffixes (tokens:>1M)
+  is:compacted     Sessions whose context was compacted
+  is:sidechain     Sessions with sub-agent work
+  is:starred       Sessions starred with Alt+S
+  tag:NAME         Sessions tagged NAME (Ctrl+L)
+  note:TEXT        Sessions whose note contains TEXT (Ctrl+X)
 
 Examples:
   ccs                                Search last 60 days, files <1GB (default)
   ccs --max-age=7                    Search last 7 days only
   ccs --all                          Search everything (all time, all files)
   ccs buyer                          Search with initial query "buyer"
+  ccs 'project:api -flaky'           Search project "api", excluding "flaky"
   ccs -- --plan                      Resume with plan mode
   ccs buyer -- --plan                Search "buyer", resume with plan mode
+  ccs list --format=jsonl 'project:api flaky'   Matching sessions as JSON lines
+  ccs show 3f2a9c --grep=migration  Messages about "migration" in one session
+  ccs stats --by=week,model --all    Token usage per week and per model
+  ccs prune --older-than=90d --dry-run   What a 90-day retention would remove
+  ccs archive --older-than=90d       Compress sessions untouched for 90 days
+  ccs activity --since=2026-01-01 --hours   This year's activity by day and hour
 
 Key bindings:
   ↑/↓, Ctrl+P/N   Navigate list
   Enter           Select and resume conversation
-  Ctrl+D          Delete conversation (with confirmation)
+  Ctrl+D          Move conversation to the trash (with confirmation)
+  Ctrl+Z          Undo the last delete
+  Tab             Mark/unmark conversation (Ctrl+D, Alt+E, Alt+S, Ctrl+L act on marked ones)
+  Alt+A           Mark all listed conversations, or clear the marks
   Ctrl+J/K        Scroll preview
   Mouse wheel     Scroll list or preview (based on position)
   Ctrl+U          Clear search
+  Ctrl+R          Cycle exact/fuzzy/regex matching (regex: whole query is a pattern)
+  Ctrl+S          Cycle search scope: both/user/assistant messages
+  Ctrl+T          Show/hide thinking blocks in the preview
+  Ctrl+O          Expand/collapse sub-agent threads in the preview
+  Alt+N           Browse conversation branches (after rewinds or edited prompts)
+  Alt+E           Export the conversation to Markdown in the current directory
+  Alt+S           Star/unstar conversation
+  Ctrl+L          Edit tags (Enter to save, Esc to cancel)
+  Ctrl+X          Edit a short note
+  Ctrl+G          Show/hide token usage and cost of the listed conversations
   Esc, Ctrl+C     Quit
 
 `, version)
@@ -885,6 +1375,16 @@ Key bindings:
 func main() {
 	args := os.Args[1:]
 
+	if len(args) > 0 {
+		if run, ok := subcommands[args[0]]; ok {
+			if err := run(args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "ccs %s: %v\n", args[0], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	for _, arg := range args {
 		if arg == "-h" || arg == "--help" {
 			printHelp()
@@ -897,28 +1397,27 @@ func main() {
 	}
 
 	// Parse flags
-	maxAgeDays := 60        // Default to 60 days
-	maxSizeMB := int64(1024) // Default to 1GB
+	scan := defaultScanFlags()
+	var search searchOptions
+	redactPreview, permanent := false, false
 	for _, arg := range args {
-		if arg == "--all" {
-			maxAgeDays = 0
-			maxSizeMB = 0
-		} else if strings.HasPrefix(arg, "--max-age=") {
-			val := strings.TrimPrefix(arg, "--max-age=")
-			fmt.Sscanf(val, "%d", &maxAgeDays)
-		} else if strings.HasPrefix(arg, "--max-size=") {
-			val := strings.TrimPrefix(arg, "--max-size=")
-			fmt.Sscanf(val, "%d", &maxSizeMB)
+		if scan.parse(arg) {
+			continue
+		}
+		if arg == "--include-thinking" {
+			search.IncludeThinking = true
+		} else if arg == "--redact" {
+			redactPreview = true
+		} else if arg == "--permanent-delete" {
+			permanent = true
+		} else if strings.HasPrefix(arg, "--scope=") {
+			scope, err := parseScope(strings.TrimPrefix(arg, "--scope="))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			search.Scope = scope
 		}
-	}
-
-	// Convert to bytes (0 means no limit)
-	maxSize := maxSizeMB * 1024 * 1024
-
-	// Calculate cutoff time (0 means no limit)
-	var cutoff time.Time
-	if maxAgeDays > 0 {
-		cutoff = time.Now().AddDate(0, 0, -maxAgeDays)
 	}
 
 	// Debug mode - dump search lines
@@ -928,8 +1427,7 @@ func main() {
 			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 				filter = args[i+1]
 			}
-			conversations, _ := getConversations(cutoff, maxSize)
-			items := buildItems(conversations)
+			items, _ := loadItems(scan.cutoff(), scan.maxSize(), scan.rebuild, search)
 			for _, item := range items {
 				line := item.searchText
 				if filter != "" {
@@ -950,7 +1448,7 @@ func main() {
 			break
 		}
 		// Skip our flags when looking for filter query
-		if arg == "--all" || strings.HasPrefix(arg, "--max-age=") || strings.HasPrefix(arg, "--max-size=") {
+		if arg == "--all" || arg == "--rebuild-index" || arg == "--include-thinking" || arg == "--redact" || arg == "--permanent-delete" || strings.HasPrefix(arg, "--max-age=") || strings.HasPrefix(arg, "--max-size=") || strings.HasPrefix(arg, "--scope=") {
 			continue
 		}
 		if !strings.HasPrefix(arg, "-") && filterQuery == "" {
@@ -966,26 +1464,31 @@ func main() {
 	}
 
 	fmt.Fprint(os.Stderr, "Loading conversations...")
-	conversations, err := getConversations(cutoff, maxSize)
+	items, err := loadItems(scan.cutoff(), scan.maxSize(), scan.rebuild, search)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "\rError loading conversations: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Fprint(os.Stderr, "\r                         \r")
 
-	if len(conversations) == 0 {
-		fmt.Fprintf(os.Stderr, "No conversations found\n")
-		os.Exit(1)
-	}
-
-	items := buildItems(conversations)
 	if len(items) == 0 {
-		fmt.Fprintf(os.Stderr, "No searchable messages found\n")
+		fmt.Fprintf(os.Stderr, "No conversations found\n")
 		os.Exit(1)
 	}
 
 	// Run TUI
 	m := initialModel(items, filterQuery, claudeFlags)
+	m.search = search
+	if m.config, err = loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config: %v\n", err)
+	}
+	if r, err := newRedactor(m.config); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		m.redactor = r
+	}
+	m.redactPreview = redactPreview || m.config.Redact.Preview
+	m.permanent = permanent
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	finalModel, err := p.Run()
@@ -1000,6 +1503,13 @@ func main() {
 	}
 
 	conv := final.selected
+	// Claude Code only resumes sessions in its projects directory
+	if isArchived(conv.FilePath) {
+		if err := unarchiveConversation(*conv); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not restore archived session: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	cwd := conv.Cwd
 	if cwd == "" || cwd == "unknown" {
 		cwd = "."
@@ -131,7 +131,7 @@ func TestBuildItems(t *testing.T) {
 		},
 	}
 
-	items := buildItems(conversations)
+	items := buildItems(conversations, searchOptions{})
 
 	// Should have exactly one item per conversation
 	if len(items) != 2 {
@@ -177,7 +177,7 @@ func TestBuildItemsNoUserMessages(t *testing.T) {
 		},
 	}
 
-	items := buildItems(conversations)
+	items := buildItems(conversations, searchOptions{})
 
 	// Should still have one item (we include all conversations now)
 	if len(items) != 1 {
@@ -185,6 +185,41 @@ func TestBuildItemsNoUserMessages(t *testing.T) {
 	}
 }
 
+func TestBuildSearchTextScope(t *testing.T) {
+	conv := Conversation{
+		SessionID: "session1",
+		Messages: []Message{
+			{Role: "user", Text: "migrate the database"},
+			{Role: "assistant", Text: "here is the migration plan"},
+		},
+	}
+
+	tests := []struct {
+		scope         searchScope
+		wantUser      bool
+		wantAssistant bool
+	}{
+		{scopeBoth, true, true},
+		{scopeUser, true, false},
+		{scopeAssistant, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope.String(), func(t *testing.T) {
+			text := buildSearchText(conv, searchOptions{Scope: tt.scope})
+			if strings.Contains(text, "migrate the database") != tt.wantUser {
+				t.Errorf("user text included = %v, want %v", !tt.wantUser, tt.wantUser)
+			}
+			if strings.Contains(text, "migration plan") != tt.wantAssistant {
+				t.Errorf("assistant text included = %v, want %v", !tt.wantAssistant, tt.wantAssistant)
+			}
+			if !strings.Contains(text, "session1") {
+				t.Error("session ID should always be searchable")
+			}
+		})
+	}
+}
+
 func TestBuildItemsProjectExtraction(t *testing.T) {
 	conversations := []Conversation{
 		{
@@ -198,7 +233,7 @@ func TestBuildItemsProjectExtraction(t *testing.T) {
 		},
 	}
 
-	items := buildItems(conversations)
+	items := buildItems(conversations, searchOptions{})
 
 	// Search text should contain full path
 	if !strings.Contains(items[0].searchText, "/home/user/my-project") {
@@ -229,6 +264,29 @@ func TestHighlight(t *testing.T) {
 	}
 }
 
+// parseJSONLFixture writes lines to a session file and parses it
+func parseJSONLFixture(t *testing.T, lines ...string) *Conversation {
+	t.Helper()
+	testFile := filepath.Join(t.TempDir(), "fixture.jsonl")
+	if err := os.WriteFile(testFile, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conv, err := parseConversationFile(testFile, time.Time{}, 0)
+	if err != nil || conv == nil {
+		t.Fatalf("parseConversationFile failed: %v", err)
+	}
+	return conv
+}
+
+// messageKinds lists the role/kind of each message, for comparing parses
+func messageKinds(conv *Conversation) string {
+	var kinds []string
+	for _, msg := range conv.Messages {
+		kinds = append(kinds, msg.Role+"/"+msg.Kind)
+	}
+	return strings.Join(kinds, " ")
+}
+
 func TestParseConversationFile(t *testing.T) {
 	// Create a temp file with test conversation
 	tmpDir := t.TempDir()
@@ -272,11 +330,71 @@ func TestParseConversationFile(t *testing.T) {
 	}
 }
 
-func TestParseConversationFileSkipsAgentFiles(t *testing.T) {
+func TestParseConversationFileGitBranch(t *testing.T) {
+	conv := parseJSONLFixture(t,
+		`{"type":"user","cwd":"/test","gitBranch":"main","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}`,
+		`{"type":"assistant","gitBranch":"feature/x","message":{"content":"hi"},"timestamp":"2024-01-15T10:01:00Z"}`,
+		`{"type":"user","message":{"content":"bye"},"timestamp":"2024-01-15T10:02:00Z"}`,
+	)
+	if conv.GitBranch != "feature/x" {
+		t.Errorf("GitBranch = %q, want most recent branch %q", conv.GitBranch, "feature/x")
+	}
+}
+
+func TestParseConversationFileSummary(t *testing.T) {
+	messages := []string{
+		`{"type":"user","uuid":"a","cwd":"/test","message":{"content":"continue"},"timestamp":"2024-01-15T10:00:00Z"}`,
+		`{"type":"assistant","uuid":"b","parentUuid":"a","message":{"content":"ok"},"timestamp":"2024-01-15T10:01:00Z"}`,
+	}
+	tests := []struct {
+		name      string
+		summaries []string
+		want      string
+	}{
+		{"latest wins", []string{
+			`{"type":"summary","summary":"Old title","leafUuid":"a"}`,
+			`{"type":"summary","summary":"Debug Redis timeouts in checkout","leafUuid":"b"}`,
+		}, "Debug Redis timeouts in checkout"},
+		{"another session's summary", []string{
+			`{"type":"summary","summary":"Debug Redis timeouts in checkout","leafUuid":"b"}`,
+			`{"type":"summary","summary":"Rename the billing tables","leafUuid":"elsewhere"}`,
+		}, "Debug Redis timeouts in checkout"},
+		{"only foreign summaries", []string{
+			`{"type":"summary","summary":"Rename the billing tables","leafUuid":"elsewhere"}`,
+			`{"type":"summary","summary":"No leaf"}`,
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := parseJSONLFixture(t, append(tt.summaries, messages...)...)
+			if conv.Summary != tt.want {
+				t.Errorf("Summary = %q, want %q", conv.Summary, tt.want)
+			}
+			if len(conv.Messages) != 2 {
+				t.Errorf("summary lines should not become messages, got %d messages", len(conv.Messages))
+			}
+			if tt.want == "" && getTopic(*conv) != "continue" {
+				t.Errorf("topic = %q, want the first prompt", getTopic(*conv))
+			}
+		})
+	}
+
+	conv := parseJSONLFixture(t, append([]string{`{"type":"summary","summary":"Debug Redis timeouts in checkout","leafUuid":"b"}`}, messages...)...)
+	item := buildItems([]Conversation{*conv}, searchOptions{})[0]
+	m := initialModel([]listItem{item}, "", nil)
+	if line := m.formatListItem(item, false); !strings.Contains(line, "Debug Redis timeouts") {
+		t.Errorf("list item should show the summary as topic, got %q", line)
+	}
+	if !strings.Contains(item.searchText, "Redis timeouts") {
+		t.Error("summary should be searchable")
+	}
+}
+
+func TestParseConversationFileAgent(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "agent-test.jsonl")
 
-	content := `{"type":"user","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}`
+	content := `{"type":"user","sessionId":"parent-1","isSidechain":true,"message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}`
 	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
 		t.Fatalf("failed to write test file: %v", err)
 	}
@@ -286,8 +404,8 @@ func TestParseConversationFileSkipsAgentFiles(t *testing.T) {
 		t.Fatalf("parseConversationFile failed: %v", err)
 	}
 
-	if conv != nil {
-		t.Error("parseConversationFile should return nil for agent- prefixed files")
+	if conv == nil || conv.ParentID != "parent-1" {
+		t.Fatalf("agent file should parse with its parent session, got %+v", conv)
 	}
 }
 
@@ -378,6 +496,145 @@ func TestParseConversationFileSkipsLargeFiles(t *testing.T) {
 	}
 }
 
+func TestResumeConversationFileAppends(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "growing-session.jsonl")
+
+	content := `{"type":"user","cwd":"/test/project","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}
+{"type":"assistant","message":{"content":"hi there"},"timestamp":"2024-01-15T10:01:00Z"}
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	conv, state, err := resumeConversationFile(testFile, nil, parseState{})
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if state.Offset != int64(len(content)) {
+		t.Errorf("Offset = %d, want %d", state.Offset, len(content))
+	}
+
+	appended := `{"type":"user","message":{"content":"more"},"timestamp":"2024-01-15T10:02:00Z"}
+`
+	f, err := os.OpenFile(testFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	f.WriteString(appended)
+	f.Close()
+
+	resumed, state2, err := resumeConversationFile(testFile, conv, state)
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if len(resumed.Messages) != 3 {
+		t.Fatalf("len(Messages) = %d, want 3", len(resumed.Messages))
+	}
+	if resumed.Messages[2].Text != "more" {
+		t.Errorf("appended message = %q, want %q", resumed.Messages[2].Text, "more")
+	}
+	if resumed.LastTimestamp != "2024-01-15T10:02:00Z" {
+		t.Errorf("LastTimestamp = %q, want %q", resumed.LastTimestamp, "2024-01-15T10:02:00Z")
+	}
+	if resumed.Cwd != "/test/project" {
+		t.Errorf("Cwd = %q, want %q", resumed.Cwd, "/test/project")
+	}
+	if state2.Offset != int64(len(content)+len(appended)) {
+		t.Errorf("Offset = %d, want %d", state2.Offset, len(content)+len(appended))
+	}
+
+	// The previous result must not be modified
+	if len(conv.Messages) != 2 {
+		t.Errorf("previous conversation was modified, has %d messages", len(conv.Messages))
+	}
+}
+
+func TestResumeConversationFileFallsBackToFullParse(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "rewritten-session.jsonl")
+
+	content := `{"type":"user","cwd":"/test","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}
+{"type":"assistant","message":{"content":"hi there"},"timestamp":"2024-01-15T10:01:00Z"}
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	conv, state, err := resumeConversationFile(testFile, nil, parseState{})
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+
+	// Shrunk file
+	shrunk := `{"type":"user","cwd":"/test","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}
+`
+	if err := os.WriteFile(testFile, []byte(shrunk), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	resumed, _, err := resumeConversationFile(testFile, conv, state)
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if len(resumed.Messages) != 1 {
+		t.Errorf("shrunk file should be fully re-parsed, got %d messages", len(resumed.Messages))
+	}
+
+	// Same length or longer but a different header
+	rewritten := `{"type":"user","cwd":"/else","message":{"content":"howdy"},"timestamp":"2024-01-15T10:00:00Z"}
+{"type":"assistant","message":{"content":"hi there"},"timestamp":"2024-01-15T10:01:00Z"}
+{"type":"user","message":{"content":"bye"},"timestamp":"2024-01-15T10:02:00Z"}
+`
+	if err := os.WriteFile(testFile, []byte(rewritten), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	resumed, _, err = resumeConversationFile(testFile, conv, state)
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if len(resumed.Messages) != 3 || resumed.Messages[0].Text != "howdy" {
+		t.Errorf("rewritten file should be fully re-parsed, got %+v", resumed.Messages)
+	}
+	if resumed.Cwd != "/else" {
+		t.Errorf("Cwd = %q, want %q", resumed.Cwd, "/else")
+	}
+}
+
+func TestResumeConversationFileSkipsPartialLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "partial-session.jsonl")
+
+	complete := `{"type":"user","cwd":"/test","message":{"content":"hello"},"timestamp":"2024-01-15T10:00:00Z"}
+`
+	partial := `{"type":"assistant","message":{"content":"hi th`
+	if err := os.WriteFile(testFile, []byte(complete+partial), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	conv, state, err := resumeConversationFile(testFile, nil, parseState{})
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if state.Offset != int64(len(complete)) {
+		t.Errorf("Offset = %d, want %d (partial line must not be consumed)", state.Offset, len(complete))
+	}
+
+	// Finish the partial line
+	f, err := os.OpenFile(testFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	f.WriteString(`ere"},"timestamp":"2024-01-15T10:01:00Z"}` + "\n")
+	f.Close()
+
+	resumed, _, err := resumeConversationFile(testFile, conv, state)
+	if err != nil {
+		t.Fatalf("resumeConversationFile failed: %v", err)
+	}
+	if len(resumed.Messages) != 2 || resumed.Messages[1].Text != "hi there" {
+		t.Errorf("completed line should be parsed on resume, got %+v", resumed.Messages)
+	}
+}
+
 func TestGetTopic(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -405,6 +662,17 @@ func TestGetTopic(t *testing.T) {
 			},
 			expected: "test-456",
 		},
+		{
+			name: "summary preferred",
+			conv: Conversation{
+				SessionID: "test-abc",
+				Summary:   "Fix flaky auth tests",
+				Messages: []Message{
+					{Role: "user", Text: "continue"},
+				},
+			},
+			expected: "Fix flaky auth tests",
+		},
 		{
 			name: "empty messages",
 			conv: Conversation{
@@ -918,8 +1186,8 @@ func TestUpdateMouseScroll(t *testing.T) {
 }
 
 func TestDeleteConversationFullFlow(t *testing.T) {
-	// Create temp directory that will act as projects dir
-	tmpDir := t.TempDir()
+	// Point the projects dir and the trash at temp dirs
+	tmpDir := setupIndexTest(t)
 
 	// Create test conversation files
 	session1 := "delete-me"
@@ -936,11 +1204,6 @@ func TestDeleteConversationFullFlow(t *testing.T) {
 		t.Fatalf("failed to write test file: %v", err)
 	}
 
-	// Save and override getProjectsDir
-	oldGetProjectsDir := getProjectsDir
-	getProjectsDir = func() string { return tmpDir }
-	defer func() { getProjectsDir = oldGetProjectsDir }()
-
 	// Create items
 	items := []listItem{
 		{
@@ -987,6 +1250,11 @@ func TestDeleteConversationFullFlow(t *testing.T) {
 		t.Error("file2 should still exist")
 	}
 
+	// Verify the deleted file went to the test's trash
+	if entries, _ := listTrash(); len(entries) != 1 || entries[0].SessionID != session1 {
+		t.Errorf("trash = %+v", entries)
+	}
+
 	// Verify filtered list was updated
 	if len(m.filtered) != 1 {
 		t.Errorf("filtered should have 1 item, got %d", len(m.filtered))
@@ -1030,10 +1298,11 @@ func TestGetConversations(t *testing.T) {
 		t.Fatalf("failed to write agent file: %v", err)
 	}
 
-	// Save and override getProjectsDir
-	oldGetProjectsDir := getProjectsDir
+	// Save and override getProjectsDir, and keep real archives out
+	oldGetProjectsDir, oldGetArchiveDir := getProjectsDir, getArchiveDir
 	getProjectsDir = func() string { return tmpDir }
-	defer func() { getProjectsDir = oldGetProjectsDir }()
+	getArchiveDir = func() string { return filepath.Join(tmpDir, "archive") }
+	defer func() { getProjectsDir, getArchiveDir = oldGetProjectsDir, oldGetArchiveDir }()
 
 	// Get conversations
 	convs, err := getConversations(time.Time{}, 0)
@@ -1041,7 +1310,7 @@ func TestGetConversations(t *testing.T) {
 		t.Fatalf("getConversations failed: %v", err)
 	}
 
-	// Should have 2 conversations (agent file should be skipped)
+	// Should have 2 conversations (agent file has no parent to attach to)
 	if len(convs) != 2 {
 		t.Errorf("expected 2 conversations, got %d", len(convs))
 	}
@@ -1060,4 +1329,3 @@ func TestPrintHelp(t *testing.T) {
 	// but this at least ensures the function doesn't crash
 	printHelp()
 }
-
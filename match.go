@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchMode selects how the search box is matched against conversations
+type searchMode int
+
+const (
+	modeExact searchMode = iota // Case-insensitive substring
+	modeFuzzy                   // fzf-style subsequence, ranked by score
+	modeRegex                   // The whole query is one regular expression
+)
+
+func (s searchMode) String() string {
+	switch s {
+	case modeFuzzy:
+		return "fuzzy"
+	case modeRegex:
+		return "regex"
+	default:
+		return "exact"
+	}
+}
+
+// next returns the mode the toggle key switches to
+func (s searchMode) next() searchMode {
+	return (s + 1) % 3
+}
+
+// searchScope selects whose messages are searchable
+type searchScope int
+
+const (
+	scopeBoth searchScope = iota
+	scopeUser
+	scopeAssistant
+)
+
+func (s searchScope) String() string {
+	switch s {
+	case scopeUser:
+		return "user"
+	case scopeAssistant:
+		return "assistant"
+	default:
+		return "both"
+	}
+}
+
+// next returns the scope the toggle key switches to
+func (s searchScope) next() searchScope {
+	return (s + 1) % 3
+}
+
+// includes reports whether messages with the given role are in scope.
+// Tool calls and results are part of the assistant's work.
+func (s searchScope) includes(role string) bool {
+	switch s {
+	case scopeUser:
+		return role == "user"
+	case scopeAssistant:
+		return role == "assistant" || role == "tool"
+	default:
+		return true
+	}
+}
+
+// parseMode parses a --mode value
+func parseMode(v string) (searchMode, error) {
+	for _, s := range []searchMode{modeExact, modeFuzzy, modeRegex} {
+		if v == s.String() {
+			return s, nil
+		}
+	}
+	return modeExact, fmt.Errorf("invalid mode %q (want exact, fuzzy or regex)", v)
+}
+
+// parseScope parses a --scope value
+func parseScope(v string) (searchScope, error) {
+	for _, s := range []searchScope{scopeBoth, scopeUser, scopeAssistant} {
+		if v == s.String() {
+			return s, nil
+		}
+	}
+	return scopeBoth, fmt.Errorf("invalid scope %q (want user, assistant or both)", v)
+}
+
+// searchOptions controls what goes into a conversation's searchText
+type searchOptions struct {
+	Scope           searchScope
+	IncludeThinking bool // Search assistant thinking blocks too
+}
+
+// includes reports whether a message is searchable under these options
+func (o searchOptions) includes(msg Message) bool {
+	switch msg.Kind {
+	case kindThinking:
+		return o.IncludeThinking && o.Scope != scopeUser
+	case kindCompaction:
+		return false
+	}
+	return o.Scope.includes(msg.Role)
+}
+
+// Fuzzy scoring weights, loosely modelled on fzf
+const (
+	scoreMatch       = 16 // Every matched character
+	bonusConsecutive = 8  // Character directly follows the previous match
+	bonusBoundary    = 8  // Character starts a word (after space, /, _, -, ., or camelCase)
+	bonusFirstChar   = 4  // Extra weight when the first pattern char is on a boundary
+	penaltyGapStart  = 3  // Opening a gap between matched characters
+	penaltyGapExtend = 1  // Each further skipped character
+	fuzzySpanPerRune = 4  // Max window size, per pattern rune...
+	fuzzySpanSlack   = 16 // ...plus this many bytes
+	recencyMaxBonus  = 30 // Score bonus for a conversation active today
+	recencyHalfLife  = 14 // Days for the recency bonus to halve
+)
+
+// matcher decides which conversations and messages match the search box
+type matcher struct {
+	mode   searchMode
+	query  string
+	search searchOptions // Which messages count as hits, unless role: or tool: is used
+	parsedQuery
+}
+
+// newMatcher parses the search box contents for the given mode
+func newMatcher(query string, mode searchMode) (matcher, error) {
+	if mode == modeRegex {
+		return newRegexMatcher(query)
+	}
+	pq, err := parseQuery(query)
+	if err != nil {
+		return matcher{}, err
+	}
+	return matcher{mode: mode, query: query, parsedQuery: pq}, nil
+}
+
+// newRegexMatcher treats the whole query as one regular expression
+func newRegexMatcher(query string) (matcher, error) {
+	mt := matcher{mode: modeRegex, query: query}
+	if query == "" {
+		return mt, nil
+	}
+	re, err := compileRegex(query)
+	if err != nil {
+		return matcher{}, err
+	}
+	node := textNode{text: query, re: re}
+	mt.expr = node
+	mt.terms = []textNode{node}
+	return mt, nil
+}
+
+// empty reports whether the matcher accepts everything
+func (mt matcher) empty() bool {
+	return mt.expr == nil
+}
+
+// matchItem evaluates the query against a conversation and reports how
+// well it matched (higher is better)
+func (mt matcher) matchItem(item listItem) (int, bool) {
+	if mt.empty() {
+		return 0, true
+	}
+	ctx := &evalContext{item: item, mode: mt.mode, text: item.searchText}
+	if mt.scoped() {
+		ctx.text = mt.scopedText(item.conv)
+	}
+	return mt.expr.eval(ctx)
+}
+
+// matchMessage reports whether a single message contains any of the
+// query's text terms; used for hit counts and the preview
+func (mt matcher) matchMessage(msg Message) bool {
+	if mt.scoped() && !mt.inScope(msg) {
+		return false
+	}
+	if !mt.scoped() && !mt.search.includes(msg) {
+		return false
+	}
+	for _, term := range mt.terms {
+		if _, ok := term.match(msg.Text, mt.mode); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// countHits counts the messages of a conversation, sub-agents included,
+// that matchMessage accepts
+func (mt matcher) countHits(conv Conversation) int {
+	if mt.empty() {
+		return 0
+	}
+	hits := 0
+	for _, msg := range conv.allMessages() {
+		if mt.matchMessage(msg) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// highlight marks the parts of text matched by the query's text terms
+func (mt matcher) highlight(text string) string {
+	marked := make(map[int]bool)
+	for _, term := range mt.terms {
+		term.mark(text, mt.mode, marked)
+	}
+	return highlightPositions(text, marked)
+}
+
+// markSubstrings marks every rune of each case-insensitive occurrence of
+// substr in text
+func markSubstrings(text, substr string, marked map[int]bool) {
+	lower := strings.ToLower(text)
+	substrLower := strings.ToLower(substr)
+	if substrLower == "" || len(lower) != len(text) {
+		return // Case folding changed byte offsets; skip highlighting
+	}
+	for from := 0; ; {
+		idx := strings.Index(lower[from:], substrLower)
+		if idx == -1 {
+			return
+		}
+		start := from + idx
+		end := start + len(substrLower)
+		markRange(text, start, end, marked)
+		from = end
+	}
+}
+
+// markRange marks every rune of text[start:end]
+func markRange(text string, start, end int, marked map[int]bool) {
+	for i := start; i < end; {
+		marked[i] = true
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+}
+
+// highlightPositions highlights the runes starting at the given byte offsets
+func highlightPositions(text string, marked map[int]bool) string {
+	if len(marked) == 0 {
+		return text
+	}
+	var result strings.Builder
+	inHighlight := false
+	for i, r := range text {
+		if marked[i] && !inHighlight {
+			// Yellow background, black text for highlight
+			result.WriteString("\033[43;30m")
+			inHighlight = true
+		} else if !marked[i] && inHighlight {
+			result.WriteString("\033[0m")
+			inHighlight = false
+		}
+		result.WriteRune(r)
+	}
+	if inHighlight {
+		result.WriteString("\033[0m")
+	}
+	return result.String()
+}
+
+// fuzzyMatch finds pattern as a case-insensitive subsequence of text and
+// returns its best score and the byte offsets of the matched runes. To keep
+// matches meaningful in long documents, all characters must fall within a
+// window a few times the pattern length, and a match whose gaps outweigh
+// its hits is rejected.
+//
+// Only the tightest window ending at each match is scored: a forward scan
+// finds where the next match ends and a backward scan from there finds the
+// latest start, so the text is walked about once however many runes could
+// begin a match.
+func fuzzyMatch(text, pattern string) (int, []int, bool) {
+	needle := []rune(strings.ToLower(pattern))
+	if len(needle) == 0 {
+		return 0, nil, true
+	}
+	maxSpan := len(needle)*fuzzySpanPerRune + fuzzySpanSlack
+
+	bestScore := 0
+	var best []int
+	found := false
+
+	for from := 0; from < len(text); {
+		end, ok := fuzzyEnd(text, from, needle)
+		if !ok {
+			break // Not a subsequence of the rest of the text
+		}
+		start := fuzzyStart(text, end, needle)
+		if positions, ok := fuzzyWindow(text, start, needle, maxSpan); ok {
+			score := fuzzyScore(text, positions)
+			if !found || score > bestScore {
+				bestScore, best, found = score, positions, true
+				if isBoundary(text, start) && contiguous(text, positions) {
+					break // A whole word-start run; nothing later can do better
+				}
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		from = start + size
+	}
+
+	if !found || bestScore <= 0 {
+		return 0, nil, false
+	}
+	return bestScore, best, true
+}
+
+// fuzzyEnd returns the offset of the last rune of the first match of needle
+// in text at or after from
+func fuzzyEnd(text string, from int, needle []rune) (int, bool) {
+	n := 0
+	for i := from; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.ToLower(r) == needle[n] {
+			n++
+			if n == len(needle) {
+				return i, true
+			}
+		}
+		i += size
+	}
+	return 0, false
+}
+
+// fuzzyStart returns the latest offset a match of needle ending at end can
+// start from
+func fuzzyStart(text string, end int, needle []rune) int {
+	n := len(needle) - 1
+	for i := end; ; {
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		if unicode.ToLower(r) == needle[n] {
+			if n == 0 {
+				return i
+			}
+			n--
+		}
+		_, size := utf8.DecodeLastRuneInString(text[:i])
+		i -= size
+	}
+}
+
+// fuzzyWindow greedily matches needle in text starting at start, giving up
+// once the match would exceed maxSpan bytes
+func fuzzyWindow(text string, start int, needle []rune, maxSpan int) ([]int, bool) {
+	positions := make([]int, 0, len(needle))
+	n := 0
+	for i := start; i < len(text) && i-start < maxSpan; {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.ToLower(r) == needle[n] {
+			positions = append(positions, i)
+			n++
+			if n == len(needle) {
+				return positions, true
+			}
+		}
+		i += size
+	}
+	return nil, false
+}
+
+// fuzzyScore rewards contiguous runs and word-boundary hits and penalises gaps
+func fuzzyScore(text string, positions []int) int {
+	score := 0
+	for k, pos := range positions {
+		score += scoreMatch
+		if isBoundary(text, pos) {
+			score += bonusBoundary
+			if k == 0 {
+				score += bonusFirstChar
+			}
+		}
+		if k == 0 {
+			continue
+		}
+		_, prevSize := utf8.DecodeRuneInString(text[positions[k-1]:])
+		gap := utf8.RuneCountInString(text[positions[k-1]+prevSize : pos])
+		if gap == 0 {
+			score += bonusConsecutive
+		} else {
+			score -= penaltyGapStart + (gap-1)*penaltyGapExtend
+		}
+	}
+	return score
+}
+
+// contiguous reports whether the matched runes form one unbroken run
+func contiguous(text string, positions []int) bool {
+	last := positions[len(positions)-1]
+	_, size := utf8.DecodeRuneInString(text[last:])
+	return utf8.RuneCountInString(text[positions[0]:last+size]) == len(positions)
+}
+
+// isBoundary reports whether the rune at pos starts a word
+func isBoundary(text string, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:pos])
+	cur, _ := utf8.DecodeRuneInString(text[pos:])
+	if unicode.IsLower(prev) && unicode.IsUpper(cur) {
+		return true
+	}
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+// recencyBonus favours recently active conversations when ranking
+func recencyBonus(ts string, now time.Time) int {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return 0
+	}
+	days := now.Sub(t).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return int(float64(recencyMaxBonus) * math.Pow(0.5, days/recencyHalfLife))
+}
+
+// scoredItem is a list item with its ranking score
+type scoredItem struct {
+	item  listItem
+	score int
+}
+
+// rankItems orders items by descending score; ties keep their original
+// (newest first) order
+func rankItems(scored []scoredItem) []listItem {
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	items := make([]listItem, len(scored))
+	for i, s := range scored {
+		items[i] = s.item
+	}
+	return items
+}
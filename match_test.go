@@ -0,0 +1,197 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		matches bool
+	}{
+		{"exact substring", "add auth middleware", "middleware", true},
+		{"missing letters", "fix the authMiddleware handler", "authmidlware", true},
+		{"case insensitive", "AuthMiddleware", "AUTHMIDDLEWARE", true},
+		{"out of order", "middleware", "elddim", false},
+		{"missing character", "middleware", "middlewarez", false},
+		{"too spread out", "a" + strings.Repeat(" ", 200) + "b", "ab", false},
+		{"many starts, all too spread out", strings.Repeat("a ", 5000) + strings.Repeat(" ", 200) + "b", "ab", false},
+		{"last start of many", strings.Repeat("a"+strings.Repeat(" ", 100), 50) + "ab", "ab", true},
+		{"not in a long text", strings.Repeat("error handler ", 5000), "erorhandlerxyz", false},
+		{"empty pattern", "anything", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, ok := fuzzyMatch(tt.text, tt.pattern)
+			if ok != tt.matches {
+				t.Errorf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.text, tt.pattern, ok, tt.matches)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchPrefersContiguousAndBoundaries(t *testing.T) {
+	contiguous, _, _ := fuzzyMatch("rate limiter", "limit")
+	spread, _, _ := fuzzyMatch("l i m i t", "limit")
+	if contiguous <= spread {
+		t.Errorf("contiguous score %d should beat spread score %d", contiguous, spread)
+	}
+
+	boundary, _, _ := fuzzyMatch("the limiter", "lim")
+	inner, _, _ := fuzzyMatch("unlimited", "lim")
+	if boundary <= inner {
+		t.Errorf("word-boundary score %d should beat mid-word score %d", boundary, inner)
+	}
+}
+
+func TestFuzzyMatchPicksBestOccurrence(t *testing.T) {
+	// The first 'a' only yields a gappy match; the later one is contiguous
+	text := "a x b y c then abc"
+	_, positions, ok := fuzzyMatch(text, "abc")
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if positions[0] != strings.Index(text, "abc") {
+		t.Errorf("expected match at contiguous occurrence, got positions %v", positions)
+	}
+}
+
+func TestMatcherHighlightFuzzy(t *testing.T) {
+	mt, err := newMatcher("amw", modeFuzzy)
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+	result := mt.highlight("authMiddleWare")
+
+	// a, M and W are highlighted individually, not as a single run
+	if strings.Count(result, "\033[43;30m") != 3 {
+		t.Errorf("expected 3 highlighted runs, got %q", result)
+	}
+	stripped := strings.NewReplacer("\033[43;30m", "", "\033[0m", "").Replace(result)
+	if stripped != "authMiddleWare" {
+		t.Errorf("highlighting should not change the text, got %q", stripped)
+	}
+}
+
+func TestMatcherExact(t *testing.T) {
+	mt, err := newMatcher(`"Hello World"`, modeExact)
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+	if _, ok := mt.matchItem(listItem{searchText: "say hello world"}); !ok {
+		t.Error("exact mode should match case-insensitive substring")
+	}
+	if _, ok := mt.matchItem(listItem{searchText: "hello there world"}); ok {
+		t.Error("quoted phrase should not match separated words")
+	}
+}
+
+func TestRecencyBonus(t *testing.T) {
+	now := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+	today := recencyBonus("2024-01-30T10:00:00Z", now)
+	lastMonth := recencyBonus("2023-12-30T10:00:00Z", now)
+	if today <= lastMonth {
+		t.Errorf("recent bonus %d should exceed older bonus %d", today, lastMonth)
+	}
+	if recencyBonus("garbage", now) != 0 {
+		t.Error("unparseable timestamp should get no bonus")
+	}
+}
+
+func TestUpdateFilterFuzzyRanking(t *testing.T) {
+	items := []listItem{
+		{conv: Conversation{SessionID: "spread"}, searchText: "a u t h m i d d l e w a r e"},
+		{conv: Conversation{SessionID: "none"}, searchText: "unrelated"},
+		{conv: Conversation{SessionID: "tight"}, searchText: "fix authMiddleware"},
+	}
+
+	m := initialModel(items, "authmidlware", nil)
+	if len(m.filtered) != 0 {
+		t.Errorf("exact mode should find nothing, got %d", len(m.filtered))
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = result.(model)
+	if m.mode != modeFuzzy {
+		t.Fatalf("Ctrl+R should switch to fuzzy mode, got %v", m.mode)
+	}
+	if len(m.filtered) == 0 || m.filtered[0].conv.SessionID != "tight" {
+		t.Fatalf("best fuzzy match should rank first, got %+v", m.filtered)
+	}
+	for _, item := range m.filtered {
+		if item.conv.SessionID == "none" {
+			t.Error("non-matching item should be filtered out")
+		}
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = result.(model)
+	if m.mode != modeRegex {
+		t.Errorf("Ctrl+R should switch to regex mode, got %v", m.mode)
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = result.(model)
+	if m.mode != modeExact {
+		t.Errorf("Ctrl+R should cycle back to exact mode, got %v", m.mode)
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	for _, v := range []string{"user", "assistant", "both"} {
+		scope, err := parseScope(v)
+		if err != nil || scope.String() != v {
+			t.Errorf("parseScope(%q) = %v, %v", v, scope, err)
+		}
+	}
+	if _, err := parseScope("everyone"); err == nil {
+		t.Error("parseScope should reject unknown scopes")
+	}
+}
+
+func TestUpdateScopeToggle(t *testing.T) {
+	convs := []Conversation{
+		{
+			SessionID: "plan",
+			Messages: []Message{
+				{Role: "user", Text: "how should we do this"},
+				{Role: "assistant", Text: "the migration plan is"},
+			},
+		},
+	}
+	m := initialModel(buildItems(convs, searchOptions{}), "migration", nil)
+	if len(m.filtered) != 1 {
+		t.Fatalf("default scope should search assistant replies, got %d", len(m.filtered))
+	}
+	item := m.filtered[0]
+	if !strings.Contains(m.formatListItem(item, true), "    1") {
+		t.Errorf("assistant reply should count as a hit: %q", m.formatListItem(item, true))
+	}
+
+	// both -> user
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = result.(model)
+	if m.search.Scope != scopeUser {
+		t.Fatalf("Ctrl+S should switch to user scope, got %v", m.search.Scope)
+	}
+	if len(m.filtered) != 0 {
+		t.Errorf("user scope should not match assistant text, got %d", len(m.filtered))
+	}
+	if m.match.matchMessage(Message{Role: "assistant", Text: "migration"}) {
+		t.Error("assistant messages should not be hits in user scope")
+	}
+
+	// user -> assistant
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = result.(model)
+	if m.search.Scope != scopeAssistant || len(m.filtered) != 1 {
+		t.Errorf("assistant scope should match, got scope %v with %d results", m.search.Scope, len(m.filtered))
+	}
+}
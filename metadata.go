@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenUsage is the token accounting of one API response, or a sum of them
+type tokenUsage struct {
+	Input         int `json:"input_tokens"`
+	Output        int `json:"output_tokens"`
+	CacheCreation int `json:"cache_creation_input_tokens"`
+	CacheRead     int `json:"cache_read_input_tokens"`
+}
+
+func (u tokenUsage) total() int {
+	return u.Input + u.Output + u.CacheCreation + u.CacheRead
+}
+
+func (u tokenUsage) add(o tokenUsage) tokenUsage {
+	return tokenUsage{
+		Input:         u.Input + o.Input,
+		Output:        u.Output + o.Output,
+		CacheCreation: u.CacheCreation + o.CacheCreation,
+		CacheRead:     u.CacheRead + o.CacheRead,
+	}
+}
+
+func (u tokenUsage) sub(o tokenUsage) tokenUsage {
+	return u.add(tokenUsage{-o.Input, -o.Output, -o.CacheCreation, -o.CacheRead})
+}
+
+// usageRecord is the usage of one API response
+type usageRecord struct {
+	ID    string // API message id
+	Model string
+	Ts    string
+	Usage tokenUsage
+}
+
+// addUsage records one line's usage and adds it to the conversation
+// total. A response with several content blocks is written as several
+// lines repeating the same message id and usage, so a repeat replaces the
+// previous record rather than adding to it.
+func (conv *Conversation) addUsage(id, model, ts string, usage tokenUsage) {
+	if usage.total() == 0 {
+		return
+	}
+	if n := len(conv.UsageLog); id != "" && n > 0 && conv.UsageLog[n-1].ID == id {
+		conv.Usage = conv.Usage.sub(conv.UsageLog[n-1].Usage)
+		conv.UsageLog = conv.UsageLog[:n-1]
+	}
+	conv.Usage = conv.Usage.add(usage)
+	conv.UsageLog = append(conv.UsageLog, usageRecord{ID: id, Model: model, Ts: ts, Usage: usage})
+}
+
+// addModel records a model the conversation used, once each in order of
+// first use. Placeholders such as <synthetic> aren't models.
+func (conv *Conversation) addModel(model string) {
+	if model == "" || strings.HasPrefix(model, "<") {
+		return
+	}
+	for _, m := range conv.Models {
+		if m == model {
+			return
+		}
+	}
+	conv.Models = append(conv.Models, model)
+}
+
+// totalUsage sums the tokens of the conversation and its sub-agents
+func (conv Conversation) totalUsage() tokenUsage {
+	usage := conv.Usage
+	for _, agent := range conv.Agents {
+		usage = usage.add(agent.Usage)
+	}
+	return usage
+}
+
+// metadataLine is the preview header line with the git branch, models,
+// Claude Code version and token usage, or "" if none are known
+func metadataLine(conv Conversation) string {
+	label := func(name, value string) string {
+		return "\033[1;33m" + name + ":\033[0m " + value
+	}
+	var parts []string
+	if conv.GitBranch != "" {
+		parts = append(parts, label("Git branch", conv.GitBranch))
+	}
+	if len(conv.Models) > 0 {
+		parts = append(parts, label("Model", strings.Join(conv.Models, ", ")))
+	}
+	if conv.Version != "" {
+		parts = append(parts, label("Version", conv.Version))
+	}
+	if usage := conv.totalUsage(); usage.total() > 0 {
+		parts = append(parts, label("Tokens", fmt.Sprintf("%s (in %s, out %s, cache %s)",
+			formatTokens(usage.total()), formatTokens(usage.Input), formatTokens(usage.Output),
+			formatTokens(usage.CacheCreation+usage.CacheRead))))
+	}
+	return strings.Join(parts, "  ")
+}
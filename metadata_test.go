@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The second response is split over two lines that repeat its id and usage
+var metadataSession = []string{
+	`{"type":"user","cwd":"/src/app","gitBranch":"main","version":"1.0.80","message":{"content":"speed up the build"},"timestamp":"2024-01-15T10:00:00Z"}`,
+	`{"type":"assistant","gitBranch":"main","version":"1.0.80","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":100,"output_tokens":50,"cache_read_input_tokens":2000},"content":"Looking."},"timestamp":"2024-01-15T10:01:00Z"}`,
+	`{"type":"assistant","gitBranch":"perf","version":"1.0.81","message":{"id":"msg_2","model":"claude-opus-4-1","usage":{"input_tokens":10,"output_tokens":5},"content":[{"type":"text","text":"Cached the deps."}]},"timestamp":"2024-01-15T10:02:00Z"}`,
+	`{"type":"assistant","gitBranch":"perf","version":"1.0.81","message":{"id":"msg_2","model":"claude-opus-4-1","usage":{"input_tokens":10,"output_tokens":300},"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"make"}}]},"timestamp":"2024-01-15T10:02:01Z"}`,
+	`{"type":"assistant","message":{"id":"msg_3","model":"<synthetic>","content":"No response requested."},"timestamp":"2024-01-15T10:03:00Z"}`,
+}
+
+func TestParseMetadata(t *testing.T) {
+	conv := parseJSONLFixture(t, metadataSession...)
+
+	if conv.GitBranch != "perf" || conv.Version != "1.0.81" {
+		t.Errorf("GitBranch, Version = %q, %q, want perf, 1.0.81", conv.GitBranch, conv.Version)
+	}
+	if got := strings.Join(conv.Models, ","); got != "claude-sonnet-4-5,claude-opus-4-1" {
+		t.Errorf("Models = %q", got)
+	}
+	want := tokenUsage{Input: 110, Output: 350, CacheRead: 2000}
+	if conv.Usage != want {
+		t.Errorf("Usage = %+v, want %+v (repeated ids replace, not add)", conv.Usage, want)
+	}
+
+	msg := conv.Messages[1]
+	if msg.Model != "claude-sonnet-4-5" {
+		t.Errorf("assistant message model = %q", msg.Model)
+	}
+	if conv.Messages[0].Model != "" {
+		t.Error("user messages have no model")
+	}
+}
+
+func TestMetadataQueriesAndPreview(t *testing.T) {
+	conv := parseJSONLFixture(t, metadataSession...)
+	item := buildItems([]Conversation{*conv}, searchOptions{})[0]
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"model:opus", true},
+		{"model:haiku", false},
+		{"version:1.0.8", true},
+		{"version:2", false},
+		{"tokens:>2k", true},
+		{"tokens:>1M", false},
+		{"tokens:2460", true},
+		{"branch:perf", true},
+		{"sonnet", true},
+		{"is:sidechain", false},
+	}
+	for _, tt := range tests {
+		mt, err := newMatcher(tt.query, modeExact)
+		if err != nil {
+			t.Fatalf("newMatcher(%q) failed: %v", tt.query, err)
+		}
+		if _, ok := mt.matchItem(item); ok != tt.want {
+			t.Errorf("query %q matched = %v, want %v", tt.query, ok, tt.want)
+		}
+	}
+
+	m := initialModel([]listItem{item}, "", nil)
+	preview := m.renderPreview(item, 100)
+	for _, want := range []string{"Git branch:\033[0m perf", "Model:\033[0m claude-sonnet-4-5, claude-opus-4-1", "Version:\033[0m 1.0.81", "Tokens:\033[0m 2k (in 110, out 350, cache 2k)"} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("preview header missing %q, got %q", want, preview)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pruneRules choose the sessions `ccs prune` removes. A session must meet
+// every rule given.
+type pruneRules struct {
+	olderThan  time.Duration // Last active longer ago than this
+	fewerThan  int           // Fewer chat messages than this
+	missingCwd bool          // Project directory no longer exists
+	query      string        // Matches this search query
+}
+
+// empty reports whether no rule was given. A query only counts as a rule
+// if it has something to match, or " " would select every session.
+func (r pruneRules) empty() bool {
+	if r.olderThan != 0 || r.fewerThan != 0 || r.missingCwd {
+		return false
+	}
+	pq, err := parseQuery(r.query)
+	return err == nil && pq.expr == nil
+}
+
+// matches reports whether conv meets the rules other than the query, which
+// is applied to the whole list by filterItems
+func (r pruneRules) matches(conv Conversation, now time.Time) bool {
+	if r.olderThan > 0 {
+		// A session whose age is unknown is kept
+		last, ok := parseTimestamp(conv.LastTimestamp)
+		if !ok || now.Sub(last) <= r.olderThan {
+			return false
+		}
+	}
+	if r.fewerThan > 0 && conv.chatMessageCount() >= r.fewerThan {
+		return false
+	}
+	if r.missingCwd {
+		// No recorded directory isn't a missing one
+		if conv.Cwd == "" || conv.Cwd == "unknown" {
+			return false
+		}
+		if _, err := os.Stat(conv.Cwd); !os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneCandidates returns the items meeting the rules
+func pruneCandidates(items []listItem, rules pruneRules, now time.Time) ([]listItem, error) {
+	items, err := filterItems(items, rules.query)
+	if err != nil {
+		return nil, err
+	}
+	var kept []listItem
+	for _, item := range items {
+		if rules.matches(item.conv, now) {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// sessionFiles returns the paths of a conversation's session file and
+// sub-agent transcripts, and their total size
+func sessionFiles(conv Conversation) ([]string, int64) {
+	paths := conversationPaths(conv)
+	var size int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return paths, size
+}
+
+// formatSize abbreviates a size in bytes, e.g. 1.5 GB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// runPrune implements `ccs prune`: moving the sessions that meet a
+// retention rule to the trash
+func runPrune(args []string) error {
+	scan := defaultScanFlags()
+	scan.maxAgeDays, scan.maxSizeMB = 0, 0 // The rules decide, and big files are what prune is for
+	var rules pruneRules
+	dryRun := false
+	for _, arg := range args {
+		switch {
+		case scan.parse(arg):
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--missing-cwd":
+			rules.missingCwd = true
+		case strings.HasPrefix(arg, "--older-than="):
+			age, err := parseAge(strings.TrimPrefix(arg, "--older-than="))
+			if err != nil {
+				return err
+			}
+			rules.olderThan = age
+		case strings.HasPrefix(arg, "--fewer-than="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--fewer-than="))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --fewer-than value %q (want a message count)", strings.TrimPrefix(arg, "--fewer-than="))
+			}
+			rules.fewerThan = n
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		case rules.query != "":
+			return fmt.Errorf("expected one query, got %q and %q", rules.query, arg)
+		default:
+			rules.query = arg
+		}
+	}
+	if rules.empty() {
+		return fmt.Errorf("usage: ccs prune [--older-than=AGE] [--fewer-than=N] [--missing-cwd] [--dry-run] [query]\ngive at least one rule")
+	}
+
+	items, err := scan.load(searchOptions{})
+	if err != nil {
+		return err
+	}
+	candidates, err := pruneCandidates(items, rules, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	files, pruned := 0, 0
+	for _, item := range candidates {
+		paths, size := sessionFiles(item.conv)
+		if dryRun {
+			for _, path := range paths {
+				fmt.Println(path)
+			}
+		} else if _, err := trashConversation(item.conv); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", item.conv.SessionID, err)
+			continue
+		}
+		total += size
+		files += len(paths)
+		pruned++
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould move %d of %d sessions (%d files, %s) to the trash\n", pruned, len(items), files, formatSize(total))
+		return nil
+	}
+	fmt.Printf("Moved %d of %d sessions (%d files, %s) to the trash\n", pruned, len(items), files, formatSize(total))
+	if pruned > 0 {
+		fmt.Println("Undo with `ccs trash restore <session>`; free the space with `ccs trash purge --all`")
+	}
+	if pruned < len(candidates) {
+		return fmt.Errorf("%d sessions could not be moved", len(candidates)-pruned)
+	}
+	return nil
+}
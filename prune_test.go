@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	existing := t.TempDir()
+	conv := func(id, cwd, ts string, messages int) listItem {
+		c := Conversation{SessionID: id, Cwd: cwd, LastTimestamp: ts}
+		for i := 0; i < messages; i++ {
+			c.Messages = append(c.Messages, Message{Role: "user", Text: "deploy " + id})
+		}
+		return listItem{conv: c, searchText: buildSearchText(c, searchOptions{})}
+	}
+	items := []listItem{
+		conv("old-short", existing, "2024-01-01T00:00:00Z", 1),
+		conv("old-long", existing, "2024-01-01T00:00:00Z", 10),
+		conv("new-short", existing, "2024-05-30T00:00:00Z", 1),
+		conv("gone", "/no/such/dir", "2024-05-30T00:00:00Z", 5),
+		conv("undated", existing, "", 1),
+		conv("no-cwd", "unknown", "2024-05-30T00:00:00Z", 5),
+	}
+
+	tests := []struct {
+		rules pruneRules
+		want  []string
+	}{
+		{pruneRules{olderThan: 90 * 24 * time.Hour}, []string{"old-short", "old-long"}},
+		{pruneRules{olderThan: 90 * 24 * time.Hour, fewerThan: 3}, []string{"old-short"}},
+		{pruneRules{fewerThan: 3}, []string{"old-short", "new-short", "undated"}},
+		{pruneRules{missingCwd: true}, []string{"gone"}}, // Not no-cwd, whose cwd is the "unknown" placeholder
+		{pruneRules{query: "deploy new"}, []string{"new-short"}},
+	}
+	for _, tt := range tests {
+		got, err := pruneCandidates(items, tt.rules, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, item := range got {
+			ids = append(ids, item.conv.SessionID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.rules, ids, tt.want)
+		}
+	}
+}
+
+func TestRunPrune(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	trash := t.TempDir()
+	oldGetTrashDir := getTrashDir
+	getTrashDir = func() string { return trash }
+	t.Cleanup(func() { getTrashDir = oldGetTrashDir })
+
+	os.MkdirAll(dir, 0755)
+	write := func(id, ts string) string {
+		path := filepath.Join(dir, id+".jsonl")
+		line := `{"type":"user","sessionId":"` + id + `","cwd":"/src/api","message":{"content":"hello"},"timestamp":"` + ts + `"}` + "\n"
+		os.WriteFile(path, []byte(line), 0644)
+		return path
+	}
+	old := write("old", "2020-01-01T00:00:00Z")
+	recent := write("recent", time.Now().UTC().Format(time.RFC3339))
+
+	for _, args := range [][]string{nil, {" "}, {"--dry-run", "  \t"}} {
+		if err := runPrune(args); err == nil {
+			t.Errorf("prune %q has no rule and should refuse", args)
+		}
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Fatal("a refused prune should leave the files alone")
+	}
+	if err := runPrune([]string{"--older-than=30d", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Fatal("--dry-run should leave the files alone")
+	}
+
+	if err := runPrune([]string{"--older-than=30d"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("the old session should have been moved")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Error("the recent session should be kept")
+	}
+	if entries, _ := listTrash(); len(entries) != 1 || entries[0].SessionID != "old" {
+		t.Errorf("trash = %+v", entries)
+	}
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The search box accepts a small query language:
+//
+//	project:billing role:assistant after:2026-09-01 "rate limiter" -flaky
+//
+// Whitespace-separated terms are ANDed; OR, AND and NOT (upper case) and
+// parentheses combine them; a leading - negates a term; double quotes make
+// a phrase that always matches exactly and /slashes/ a regular expression.
+// field:value qualifiers are listed in queryFields. Bare words use the
+// current search mode (exact or fuzzy). In regex mode the whole search box
+// is a single regular expression instead.
+
+// queryNode is a node of a parsed query
+type queryNode interface {
+	eval(ctx *evalContext) (int, bool)
+}
+
+// evalContext is what a query is evaluated against
+type evalContext struct {
+	item listItem
+	mode searchMode
+	text string // searchText, or only the scoped messages when role: or tool: is used
+}
+
+type andNode struct{ children []queryNode }
+type orNode struct{ children []queryNode }
+type notNode struct{ child queryNode }
+
+// textNode matches free text against the conversation's searchable content
+type textNode struct {
+	text   string
+	phrase bool           // Quoted: always an exact substring, even in fuzzy mode
+	re     *regexp.Regexp // Set for /regex/ terms and in regex mode
+}
+
+// predicateNode checks a field of the conversation
+type predicateNode struct {
+	test func(conv Conversation) bool
+}
+
+func (n andNode) eval(ctx *evalContext) (int, bool) {
+	total := 0
+	for _, child := range n.children {
+		score, ok := child.eval(ctx)
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func (n orNode) eval(ctx *evalContext) (int, bool) {
+	best, matched := 0, false
+	for _, child := range n.children {
+		if score, ok := child.eval(ctx); ok && (!matched || score > best) {
+			best, matched = score, true
+		}
+	}
+	return best, matched
+}
+
+func (n notNode) eval(ctx *evalContext) (int, bool) {
+	_, ok := n.child.eval(ctx)
+	return 0, !ok
+}
+
+func (n textNode) eval(ctx *evalContext) (int, bool) {
+	return n.match(ctx.text, ctx.mode)
+}
+
+func (n predicateNode) eval(ctx *evalContext) (int, bool) {
+	return 0, n.test(ctx.item.conv)
+}
+
+// match matches the term against text in the given mode
+func (n textNode) match(text string, mode searchMode) (int, bool) {
+	switch {
+	case n.re != nil:
+		return 0, n.re.MatchString(text)
+	case n.phrase || mode != modeFuzzy:
+		return 0, strings.Contains(strings.ToLower(text), strings.ToLower(n.text))
+	}
+	score, _, ok := fuzzyMatch(text, n.text)
+	return score, ok
+}
+
+// mark records the byte offsets of the runes in text the term matches
+func (n textNode) mark(text string, mode searchMode, marked map[int]bool) {
+	switch {
+	case n.re != nil:
+		for _, loc := range n.re.FindAllStringIndex(text, -1) {
+			markRange(text, loc[0], loc[1], marked)
+		}
+	case n.phrase || mode != modeFuzzy:
+		markSubstrings(text, n.text, marked)
+	default:
+		if _, positions, ok := fuzzyMatch(text, n.text); ok {
+			for _, p := range positions {
+				marked[p] = true
+			}
+		}
+	}
+}
+
+// compileRegex compiles a case-insensitive search regex. Go's RE2 engine
+// runs in linear time, so no pattern can hang the search.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		// Report the error against the user's pattern, without the flag
+		if _, plain := regexp.Compile(pattern); plain != nil {
+			err = plain
+		}
+		return nil, fmt.Errorf("invalid regex: %v", strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	return re, nil
+}
+
+// queryFields maps each field qualifier to a function building its predicate
+var queryFields = map[string]func(value string) (queryNode, error){
+	"project": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool {
+			return containsFold(filepath.Base(c.Cwd), v)
+		}}, nil
+	},
+	"cwd": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool { return containsFold(c.Cwd, v) }}, nil
+	},
+	"session": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool { return containsFold(c.SessionID, v) }}, nil
+	},
+	"branch": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool { return containsFold(c.GitBranch, v) }}, nil
+	},
+	"role": func(v string) (queryNode, error) {
+		role, err := parseRole(v)
+		if err != nil {
+			return nil, err
+		}
+		return predicateNode{func(c Conversation) bool {
+			for _, msg := range c.allMessages() {
+				if msg.Role == role {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	},
+	"after": func(v string) (queryNode, error) {
+		day, err := parseQueryDate(v)
+		if err != nil {
+			return nil, err
+		}
+		return predicateNode{func(c Conversation) bool {
+			last, ok := parseTimestamp(c.LastTimestamp)
+			return ok && !last.Before(day)
+		}}, nil
+	},
+	"before": func(v string) (queryNode, error) {
+		day, err := parseQueryDate(v)
+		if err != nil {
+			return nil, err
+		}
+		return predicateNode{func(c Conversation) bool {
+			first, ok := parseTimestamp(c.FirstTimestamp)
+			return ok && first.Before(day)
+		}}, nil
+	},
+	"date": func(v string) (queryNode, error) {
+		day, err := parseQueryDate(v)
+		if err != nil {
+			return nil, err
+		}
+		next := day.AddDate(0, 0, 1)
+		return predicateNode{func(c Conversation) bool {
+			first, ok1 := parseTimestamp(c.FirstTimestamp)
+			last, ok2 := parseTimestamp(c.LastTimestamp)
+			return ok1 && ok2 && first.Before(next) && !last.Before(day)
+		}}, nil
+	},
+	"msgs": func(v string) (queryNode, error) {
+		cmp, err := parseComparison(v)
+		if err != nil {
+			return nil, err
+		}
+		return predicateNode{func(c Conversation) bool { return cmp(c.chatMessageCount()) }}, nil
+	},
+	"tool": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool {
+			for _, msg := range c.allMessages() {
+				if msg.Kind == kindToolUse && strings.EqualFold(msg.Tool.Name, v) {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	},
+	"file": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool {
+			for _, msg := range c.allMessages() {
+				if msg.Kind == kindToolUse && containsFold(msg.Tool.Path, v) {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	},
+	"model": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool {
+			for _, model := range c.Models {
+				if containsFold(model, v) {
+					return true
+				}
+			}
+			for _, agent := range c.Agents {
+				for _, model := range agent.Models {
+					if containsFold(model, v) {
+						return true
+					}
+				}
+			}
+			return false
+		}}, nil
+	},
+	"version": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool { return strings.HasPrefix(c.Version, v) }}, nil
+	},
+	"tokens": func(v string) (queryNode, error) {
+		cmp, err := parseComparison(v)
+		if err != nil {
+			return nil, err
+		}
+		return predicateNode{func(c Conversation) bool { return cmp(c.totalUsage().total()) }}, nil
+	},
+	"tag": func(v string) (queryNode, error) {
+		v = strings.TrimPrefix(v, "#")
+		return predicateNode{func(c Conversation) bool { return c.Annotation.hasTag(v) }}, nil
+	},
+	"note": func(v string) (queryNode, error) {
+		return predicateNode{func(c Conversation) bool { return containsFold(c.Annotation.Note, v) }}, nil
+	},
+	"is": func(v string) (queryNode, error) {
+		flag, ok := conversationFlags[strings.ToLower(v)]
+		if !ok {
+			names := make([]string, 0, len(conversationFlags))
+			for name := range conversationFlags {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(names, ", "), v)
+		}
+		return predicateNode{flag}, nil
+	},
+}
+
+// conversationFlags are the properties is: can test for
+var conversationFlags = map[string]func(Conversation) bool{
+	"compacted": func(c Conversation) bool { return c.Compactions > 0 },
+	"sidechain": func(c Conversation) bool { return c.Sidechain || len(c.Agents) > 0 },
+	"starred":   func(c Conversation) bool { return c.Annotation.Starred },
+}
+
+// parseRole validates a role: value
+func parseRole(v string) (string, error) {
+	switch strings.ToLower(v) {
+	case "user":
+		return "user", nil
+	case "assistant", "claude":
+		return "assistant", nil
+	case "tool":
+		return "tool", nil
+	case "thinking":
+		return "thinking", nil
+	}
+	return "", fmt.Errorf("must be user, assistant, tool or thinking, got %q", v)
+}
+
+// parseQueryDate parses a YYYY-MM-DD date as the start of that local day
+func parseQueryDate(v string) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", v)
+	}
+	return day, nil
+}
+
+// parseTimestamp parses a conversation timestamp
+func parseTimestamp(ts string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, ts)
+	return t, err == nil
+}
+
+// parseComparison parses N, >N, >=N, <N or <=N into a test on an int
+func parseComparison(v string) (func(int) bool, error) {
+	ops := []struct {
+		prefix string
+		test   func(a, b int) bool
+	}{
+		{">=", func(a, b int) bool { return a >= b }},
+		{"<=", func(a, b int) bool { return a <= b }},
+		{">", func(a, b int) bool { return a > b }},
+		{"<", func(a, b int) bool { return a < b }},
+		{"=", func(a, b int) bool { return a == b }},
+		{"", func(a, b int) bool { return a == b }},
+	}
+	for _, op := range ops {
+		if !strings.HasPrefix(v, op.prefix) {
+			continue
+		}
+		n, err := parseCount(strings.TrimPrefix(v, op.prefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q (want N, >N, >=N, <N or <=N)", v)
+		}
+		return func(x int) bool { return op.test(x, n) }, nil
+	}
+	return nil, fmt.Errorf("invalid number %q", v)
+}
+
+// parseCount parses a whole number, allowing a k or M suffix (2.5k, 1M)
+func parseCount(v string) (int, error) {
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(v, "k"), strings.HasSuffix(v, "K"):
+		scale, v = 1e3, v[:len(v)-1]
+	case strings.HasSuffix(v, "M"), strings.HasSuffix(v, "m"):
+		scale, v = 1e6, v[:len(v)-1]
+	}
+	if scale == 1 {
+		return strconv.Atoi(v)
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int(f * scale), nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// ============================================================================
+// Lexer and parser
+// ============================================================================
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokRegex
+	tokField
+	tokLParen
+	tokRParen
+	tokOr
+	tokAnd
+	tokNot
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // Word, phrase or regex text, or the field value
+	field string // Field name for tokField
+}
+
+// lexQuery splits a query into tokens
+func lexQuery(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')' && runes[i+1] != '-':
+			tokens = append(tokens, token{kind: tokNot})
+			i++
+		case r == '"':
+			text, next, err := lexPhrase(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokPhrase, text: text})
+			i = next
+		case r == '/' && regexEnd(runes, i) > 0:
+			end := regexEnd(runes, i)
+			tokens = append(tokens, token{kind: tokRegex, text: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+
+			switch word {
+			case "OR":
+				tokens = append(tokens, token{kind: tokOr})
+				continue
+			case "AND":
+				tokens = append(tokens, token{kind: tokAnd})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{kind: tokNot})
+				continue
+			}
+
+			// field:value, where value may be quoted
+			if name, value, ok := strings.Cut(word, ":"); ok {
+				if _, known := queryFields[strings.ToLower(name)]; known {
+					if value == "" && i < len(runes) && runes[i] == '"' {
+						text, next, err := lexPhrase(runes, i)
+						if err != nil {
+							return nil, err
+						}
+						value, i = text, next
+					}
+					if value == "" {
+						return nil, fmt.Errorf("%s: needs a value", name)
+					}
+					tokens = append(tokens, token{kind: tokField, field: strings.ToLower(name), text: value})
+					continue
+				}
+			}
+			tokens = append(tokens, token{kind: tokWord, text: word})
+		}
+	}
+	return tokens, nil
+}
+
+// regexEnd returns the index of the slash closing a /regex/ that starts at
+// runes[start], or -1. The regex cannot contain whitespace (use \s) and the
+// closing slash must end the token, so paths such as /home/user are still
+// plain words.
+func regexEnd(runes []rune, start int) int {
+	for i := start + 1; i < len(runes) && !unicode.IsSpace(runes[i]); i++ {
+		switch runes[i] {
+		case '\\':
+			i++ // Skip escaped character, including \/
+		case '/':
+			if i == start+1 {
+				return -1
+			}
+			if i+1 == len(runes) || unicode.IsSpace(runes[i+1]) || runes[i+1] == ')' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// lexPhrase reads a double-quoted phrase starting at runes[start]
+func lexPhrase(runes []rune, start int) (string, int, error) {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] == '"' {
+			return string(runes[start+1 : i]), i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unclosed quote")
+}
+
+// queryParser is a recursive descent parser over lexed tokens:
+//
+//	or    = and { OR and }
+//	and   = unary { [AND] unary }
+//	unary = NOT unary | primary
+//	primary = ( or ) | field | phrase | word
+type queryParser struct {
+	tokens []token
+	pos    int
+	terms  []textNode      // Positive text terms, for hit counting and highlighting
+	roles  map[string]bool // Roles named by positive role: qualifiers
+	tools  map[string]bool // Lower-cased tools named by positive tool: qualifiers
+}
+
+func (p *queryParser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *queryParser) parseOr(negated bool) (queryNode, error) {
+	first, err := p.parseAnd(negated)
+	if err != nil {
+		return nil, err
+	}
+	children := []queryNode{first}
+	for t := p.peek(); t != nil && t.kind == tokOr; t = p.peek() {
+		p.pos++
+		next, err := p.parseAnd(negated)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return orNode{children}, nil
+}
+
+func (p *queryParser) parseAnd(negated bool) (queryNode, error) {
+	var children []queryNode
+	for {
+		t := p.peek()
+		if t == nil || t.kind == tokOr || t.kind == tokRParen {
+			break
+		}
+		if t.kind == tokAnd {
+			p.pos++
+			if len(children) == 0 || p.atGroupEnd() {
+				return nil, fmt.Errorf("AND needs a term on each side")
+			}
+			continue
+		}
+		node, err := p.parseUnary(negated)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 0 {
+		if p.atGroupEnd() && p.pos > 0 && p.tokens[p.pos-1].kind == tokOr ||
+			p.peek() != nil && p.peek().kind == tokOr {
+			return nil, fmt.Errorf("OR needs a term on each side")
+		}
+		return nil, fmt.Errorf("empty group")
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return andNode{children}, nil
+}
+
+// atGroupEnd reports whether no further term follows in the current group
+func (p *queryParser) atGroupEnd() bool {
+	t := p.peek()
+	return t == nil || t.kind == tokOr || t.kind == tokAnd || t.kind == tokRParen
+}
+
+func (p *queryParser) parseUnary(negated bool) (queryNode, error) {
+	t := p.peek()
+	if t != nil && t.kind == tokNot {
+		p.pos++
+		if p.atGroupEnd() {
+			return nil, fmt.Errorf("NOT needs a term")
+		}
+		child, err := p.parseUnary(!negated)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{child}, nil
+	}
+	return p.parsePrimary(negated)
+}
+
+func (p *queryParser) parsePrimary(negated bool) (queryNode, error) {
+	t := p.peek()
+	p.pos++
+	switch t.kind {
+	case tokLParen:
+		node, err := p.parseOr(negated)
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != tokRParen {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	case tokRParen:
+		return nil, fmt.Errorf("unexpected )")
+	case tokField:
+		node, err := queryFields[t.field](t.text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", t.field, err)
+		}
+		// role: and tool: also narrow which messages text terms search
+		if !negated {
+			switch t.field {
+			case "role":
+				role, _ := parseRole(t.text)
+				p.roles[role] = true
+			case "tool":
+				p.tools[strings.ToLower(t.text)] = true
+			}
+		}
+		return node, nil
+	default: // tokWord, tokPhrase, tokRegex
+		node := textNode{text: t.text, phrase: t.kind == tokPhrase}
+		if t.kind == tokRegex {
+			re, err := compileRegex(t.text)
+			if err != nil {
+				return nil, err
+			}
+			node.re = re
+		}
+		if !negated {
+			p.terms = append(p.terms, node)
+		}
+		return node, nil
+	}
+}
+
+// parsedQuery is the result of parsing the search box
+type parsedQuery struct {
+	expr  queryNode // nil for an empty query
+	terms []textNode
+	roles map[string]bool
+	tools map[string]bool
+}
+
+// scoped reports whether role: or tool: restrict which messages text
+// terms are matched against
+func (pq parsedQuery) scoped() bool {
+	return len(pq.roles) > 0 || len(pq.tools) > 0
+}
+
+// inScope reports whether text terms should be matched against msg
+func (pq parsedQuery) inScope(msg Message) bool {
+	if len(pq.roles) > 0 && !pq.roles[msg.Role] {
+		return false
+	}
+	if len(pq.tools) > 0 && (msg.Tool == nil || !pq.tools[strings.ToLower(msg.Tool.Name)]) {
+		return false
+	}
+	return true
+}
+
+// scopedText joins the text of all messages in the query's scope
+func (pq parsedQuery) scopedText(conv Conversation) string {
+	var parts []string
+	for _, msg := range conv.allMessages() {
+		if pq.inScope(msg) {
+			parts = append(parts, msg.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseQuery parses the search box contents
+func parseQuery(query string) (parsedQuery, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return parsedQuery{}, err
+	}
+	if len(tokens) == 0 {
+		return parsedQuery{}, nil
+	}
+
+	p := &queryParser{tokens: tokens, roles: make(map[string]bool), tools: make(map[string]bool)}
+	expr, err := p.parseOr(false)
+	if err != nil {
+		return parsedQuery{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return parsedQuery{}, fmt.Errorf("unexpected )")
+	}
+	return parsedQuery{expr: expr, terms: p.terms, roles: p.roles, tools: p.tools}, nil
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// runQuery returns the session IDs of the items matching query, in order
+func runQuery(t *testing.T, items []listItem, query string) []string {
+	t.Helper()
+	mt, err := newMatcher(query, modeExact)
+	if err != nil {
+		t.Fatalf("newMatcher(%q) failed: %v", query, err)
+	}
+	var ids []string
+	for _, item := range items {
+		if _, ok := mt.matchItem(item); ok {
+			ids = append(ids, item.conv.SessionID)
+		}
+	}
+	return ids
+}
+
+func TestQueryEvaluation(t *testing.T) {
+	convs := []Conversation{
+		{
+			SessionID:      "aaa-111",
+			Cwd:            "/home/user/billing",
+			GitBranch:      "feature/rate-limit",
+			FirstTimestamp: "2026-09-02T10:00:00Z",
+			LastTimestamp:  "2026-09-02T11:00:00Z",
+			Messages: []Message{
+				{Role: "user", Text: "add a rate limiter, the tests are flaky"},
+				{Role: "assistant", Text: "the rate limiter is flaky under load"},
+			},
+		},
+		{
+			SessionID:      "bbb-222",
+			Cwd:            "/home/user/billing",
+			GitBranch:      "main",
+			FirstTimestamp: "2026-09-05T10:00:00Z",
+			LastTimestamp:  "2026-09-05T11:00:00Z",
+			Messages: []Message{
+				{Role: "user", Text: "why is the rate limiter slow"},
+				{Role: "assistant", Text: "the rate limiter backs off"},
+				{Role: "user", Text: "thanks"},
+			},
+		},
+		{
+			SessionID:      "ccc-333",
+			Cwd:            "/home/user/frontend",
+			FirstTimestamp: "2026-08-20T10:00:00Z",
+			LastTimestamp:  "2026-08-20T11:00:00Z",
+			Messages: []Message{
+				{Role: "user", Text: "rate limiter banner"},
+			},
+		},
+	}
+	items := buildItems(convs, searchOptions{})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "aaa-111 bbb-222 ccc-333"},
+		{"rate limiter", "aaa-111 bbb-222 ccc-333"},
+		{`"rate limiter"`, "aaa-111 bbb-222 ccc-333"},
+		{`"limiter rate"`, ""},
+		{"project:billing", "aaa-111 bbb-222"},
+		{"project:BILLING", "aaa-111 bbb-222"},
+		{"cwd:/home/user/front", "ccc-333"},
+		{"session:bbb", "bbb-222"},
+		{"branch:feature", "aaa-111"},
+		{`branch:"feature/rate"`, "aaa-111"},
+		{"role:user banner", "ccc-333"},
+		{"role:user backs", ""},
+		{"role:assistant backs", "bbb-222"},
+		{"after:2026-09-01", "aaa-111 bbb-222"},
+		{"before:2026-09-01", "ccc-333"},
+		{"date:2026-09-05", "bbb-222"},
+		{"msgs:3", "bbb-222"},
+		{"msgs:>=2", "aaa-111 bbb-222"},
+		{"msgs:<2", "ccc-333"},
+		{"-flaky", "bbb-222 ccc-333"},
+		{"NOT flaky", "bbb-222 ccc-333"},
+		{"flaky OR banner", "aaa-111 ccc-333"},
+		{"slow AND thanks", "bbb-222"},
+		{"project:billing (flaky OR thanks)", "aaa-111 bbb-222"},
+		{"-(flaky OR banner)", "bbb-222"},
+		{`project:billing role:assistant after:2026-09-01 "rate limiter" -flaky`, "bbb-222"},
+		{"unknown:field", ""},
+		{"--flaky", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := strings.Join(runQuery(t, items, tt.query), " ")
+			if got != tt.want {
+				t.Errorf("query %q matched %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{`"unclosed`, "unclosed quote"},
+		{"(foo", "missing )"},
+		{"foo)", "unexpected )"},
+		{"()", "empty group"},
+		{"foo OR", "OR needs a term"},
+		{"OR foo", "OR needs a term"},
+		{"foo AND", "AND needs a term"},
+		{"NOT", "NOT needs a term"},
+		{"project:", "needs a value"},
+		{"after:yesterday", "invalid date"},
+		{"msgs:lots", "invalid number"},
+		{"role:robot", "role: must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parseQuery(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseQuery(%q) error = %v, want containing %q", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryErrorText(t *testing.T) {
+	// parsePrimary names the field once, validators don't repeat it, and
+	// regex errors show the pattern as typed
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"role:robot", `role: must be user, assistant, tool or thinking, got "robot"`},
+		{"is:foo", `is: must be one of compacted, sidechain, starred, got "foo"`},
+		{"/a(/", "invalid regex: missing closing ): `a(`"},
+	}
+	for _, tt := range tests {
+		if _, err := parseQuery(tt.query); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("parseQuery(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestQueryTermsForHighlighting(t *testing.T) {
+	pq, err := parseQuery(`project:x foo -bar "baz qux" (a OR NOT b)`)
+	if err != nil {
+		t.Fatalf("parseQuery failed: %v", err)
+	}
+	var terms []string
+	for _, term := range pq.terms {
+		terms = append(terms, term.text)
+	}
+	if got := strings.Join(terms, ","); got != "foo,baz qux,a" {
+		t.Errorf("positive terms = %q, want %q", got, "foo,baz qux,a")
+	}
+}
+
+func TestMatcherMessageHits(t *testing.T) {
+	mt, err := newMatcher("role:assistant limiter", modeExact)
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+	if mt.matchMessage(Message{Role: "user", Text: "rate limiter"}) {
+		t.Error("role:assistant should not count user messages as hits")
+	}
+	if !mt.matchMessage(Message{Role: "assistant", Text: "rate limiter"}) {
+		t.Error("assistant message should be a hit")
+	}
+}
+
+func TestUpdateFilterKeepsResultsOnParseError(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "aaa-111", Cwd: "/home/user/billing", Messages: []Message{{Role: "user", Text: "add a rate limiter"}}},
+		{SessionID: "ccc-333", Cwd: "/home/user/frontend", Messages: []Message{{Role: "user", Text: "rate limiter banner"}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+	m.textInput.SetValue("project:frontend")
+	m.updateFilter()
+	if len(m.filtered) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(m.filtered))
+	}
+
+	m.textInput.SetValue(`project:frontend "unclosed`)
+	m.updateFilter()
+	if len(m.filtered) != 1 || m.filtered[0].conv.SessionID != "ccc-333" {
+		t.Errorf("parse error should keep previous results, got %d", len(m.filtered))
+	}
+	if !strings.Contains(m.queryErr, "unclosed quote") {
+		t.Errorf("queryErr = %q, want parse error", m.queryErr)
+	}
+
+	m.width, m.height = 120, 30
+	if !strings.Contains(m.View(), "unclosed quote") {
+		t.Error("parse error should be shown in the view")
+	}
+
+	m.textInput.SetValue(`project:frontend "banner"`)
+	m.updateFilter()
+	if m.queryErr != "" {
+		t.Errorf("queryErr should clear once the query parses, got %q", m.queryErr)
+	}
+}
+
+func TestQueryRegexTerms(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "aaa-111", Cwd: "/home/user/billing", Messages: []Message{{Role: "user", Text: "the rate limiter is flaky"}}},
+		{SessionID: "bbb-222", Cwd: "/home/user/billing", Messages: []Message{{Role: "user", Text: "why is the rate  limiter slow"}, {Role: "user", Text: "thanks"}}},
+		{SessionID: "ccc-333", Cwd: "/home/user/frontend", Messages: []Message{{Role: "user", Text: "rate limiter banner"}}},
+	}, searchOptions{})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`/rate\s+limiter/`, "aaa-111 bbb-222 ccc-333"},
+		{`/^why/`, ""}, // searchText starts with the session ID
+		{`/fla(k|x)y/`, "aaa-111"},
+		{`/FLAKY/`, "aaa-111"},
+		{`project:billing -/flaky|thanks/`, ""},
+		{`/slow/ OR /banner/`, "bbb-222 ccc-333"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := strings.Join(runQuery(t, items, tt.query), " ")
+			if got != tt.want {
+				t.Errorf("query %q matched %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexQueryPathsAreNotRegexes(t *testing.T) {
+	tokens, err := lexQuery("/home/user/project /a b/")
+	if err != nil {
+		t.Fatalf("lexQuery failed: %v", err)
+	}
+	if len(tokens) != 3 || tokens[0].kind != tokWord || tokens[0].text != "/home/user/project" {
+		t.Errorf("path should lex as a word, got %+v", tokens)
+	}
+	if tokens[1].kind != tokWord || tokens[2].kind != tokWord {
+		t.Errorf("unterminated regex should lex as words, got %+v", tokens)
+	}
+
+	tokens, err = lexQuery(`(/a\/(b|c)/)`)
+	if err != nil {
+		t.Fatalf("lexQuery failed: %v", err)
+	}
+	if len(tokens) != 3 || tokens[1].kind != tokRegex || tokens[1].text != `a\/(b|c)` {
+		t.Errorf("regex with escaped slash and parens should lex as one token, got %+v", tokens)
+	}
+}
+
+func TestRegexMode(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "aaa-111", Cwd: "/home/user/billing", Messages: []Message{{Role: "user", Text: "add a rate limiter"}}},
+		{SessionID: "bbb-222", Cwd: "/home/user/billing", Messages: []Message{{Role: "user", Text: "why is the rate limiter slow"}}},
+		{SessionID: "ccc-333", Cwd: "/home/user/frontend", Messages: []Message{{Role: "user", Text: "rate limiter banner"}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+	m.mode = modeRegex
+
+	m.textInput.SetValue(`limiter (slow|banner)`)
+	m.updateFilter()
+	if len(m.filtered) != 2 {
+		t.Fatalf("regex should match 2 conversations, got %d", len(m.filtered))
+	}
+
+	// An invalid pattern keeps the last valid results and reports the error
+	m.textInput.SetValue(`limiter (slow|banner`)
+	m.updateFilter()
+	if len(m.filtered) != 2 {
+		t.Errorf("invalid regex should keep previous results, got %d", len(m.filtered))
+	}
+	if !strings.Contains(m.queryErr, "invalid regex") {
+		t.Errorf("queryErr = %q, want regex compile error", m.queryErr)
+	}
+
+	// Qualifiers are not special in regex mode
+	m.textInput.SetValue(`project:billing`)
+	m.updateFilter()
+	if len(m.filtered) != 0 {
+		t.Errorf("regex mode should treat the query literally, got %d", len(m.filtered))
+	}
+}
+
+func TestRegexHitsAndHighlight(t *testing.T) {
+	mt, err := newMatcher(`lim\w+`, modeRegex)
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+
+	if !mt.matchMessage(Message{Role: "user", Text: "the Limiter"}) {
+		t.Error("regex should count case-insensitive hits")
+	}
+	if mt.matchMessage(Message{Role: "user", Text: "lim"}) {
+		t.Error("regex should not count non-matching messages")
+	}
+
+	result := mt.highlight("a limiter and limits")
+	if strings.Count(result, "\033[43;30m") != 2 {
+		t.Errorf("expected 2 highlighted matches, got %q", result)
+	}
+	if !strings.Contains(result, "\033[43;30mlimiter\033[0m") {
+		t.Errorf("whole regex match should be highlighted, got %q", result)
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"42", 42},
+		{"2k", 2000},
+		{"2.5K", 2500},
+		{"1M", 1000000},
+	}
+	for _, tt := range tests {
+		if got, err := parseCount(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseCount(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseCount("lots"); err == nil {
+		t.Error("parseCount should reject non-numbers")
+	}
+}
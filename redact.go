@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// secretDetector finds one kind of secret. When the pattern has a capture
+// group, only the group is the secret (e.g. the value of KEY=value).
+type secretDetector struct {
+	name  string
+	re    *regexp.Regexp
+	check func(s string) bool // Extra test of each match, if any
+}
+
+// builtinDetectors find well-known credential formats, most specific
+// first; the generic detectors at the end only catch what the others miss
+var builtinDetectors = []secretDetector{
+	{name: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{name: "anthropic-key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{name: "openai-key", re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{name: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{name: "gitlab-token", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "slack-token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "stripe-key", re: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{24,}`)},
+	{name: "bearer-token", re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{20,}=*)`)},
+	{name: "env-secret", re: regexp.MustCompile(`(?im)^\s*(?:export\s+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_KEY|ACCESS_KEY|CREDENTIALS?)[A-Z0-9_]*\s*[=:]\s*["']?([^\s"'#]{8,})`)},
+	// Random-looking strings are only flagged as the value of a key, token,
+	// secret or password, since long paths and identifiers look random too
+	{name: "high-entropy", re: regexp.MustCompile(`(?i)(?:key|token|secret|passw(?:or)?d|credentials?)[a-z0-9_-]*["']?\s*[:=]\s*["']?([A-Za-z0-9+/_-]{32,}={0,2})`), check: highEntropy},
+}
+
+// highEntropy reports whether s looks like random key material: a mix of
+// upper and lower case letters and digits with more than 4.2 bits of
+// entropy per character. Hex strings such as commit hashes and UUIDs can't
+// exceed 4.1 bits, so they aren't flagged.
+func highEntropy(s string) bool {
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy > 4.2
+}
+
+// secretMatch is where a detector found a secret in a text
+type secretMatch struct {
+	kind       string
+	start, end int
+}
+
+// redactor finds and masks secrets
+type redactor struct {
+	detectors []secretDetector
+}
+
+// newRedactor combines the user's patterns, which take precedence, with
+// the built-in detectors
+func newRedactor(cfg config) (*redactor, error) {
+	r := &redactor{}
+	for _, pattern := range cfg.Redact.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", pattern, err)
+		}
+		r.detectors = append(r.detectors, secretDetector{name: "custom", re: re})
+	}
+	r.detectors = append(r.detectors, builtinDetectors...)
+	return r, nil
+}
+
+// find returns the secrets in text in order, without overlaps. Where two
+// detectors match overlapping text, the one listed first wins.
+func (r *redactor) find(text string) []secretMatch {
+	var found []secretMatch
+	overlaps := func(start, end int) bool {
+		for _, m := range found {
+			if start < m.end && m.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range r.detectors {
+		for _, loc := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start == end || overlaps(start, end) || (d.check != nil && !d.check(text[start:end])) {
+				continue
+			}
+			found = append(found, secretMatch{kind: d.name, start: start, end: end})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	return found
+}
+
+// redact replaces each secret in text with a [REDACTED:kind] marker
+func (r *redactor) redact(text string) string {
+	if r == nil {
+		return text
+	}
+	matches := r.find(text)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.start])
+		b.WriteString("[REDACTED:" + m.kind + "]")
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// conversation returns a copy of conv, sub-agents included, with secrets
+// masked in its summary, messages and tool inputs. A nil redactor returns
+// conv unchanged.
+func (r *redactor) conversation(conv Conversation) Conversation {
+	if r == nil {
+		return conv
+	}
+	conv.Summary = r.redact(conv.Summary)
+	messages := make([]Message, len(conv.Messages))
+	for i, msg := range conv.Messages {
+		msg.Text = r.redact(msg.Text)
+		if msg.Tool != nil {
+			call := *msg.Tool
+			call.Input = r.redact(call.Input)
+			msg.Tool = &call
+		}
+		messages[i] = msg
+	}
+	conv.Messages = messages
+	if len(conv.Agents) > 0 {
+		agents := make([]Conversation, len(conv.Agents))
+		for i, agent := range conv.Agents {
+			agents[i] = r.conversation(agent)
+		}
+		conv.Agents = agents
+	}
+	return conv
+}
+
+// previewText masks the secrets in text shown in the TUI, when enabled
+func (m model) previewText(text string) string {
+	if !m.redactPreview {
+		return text
+	}
+	return m.redactor.redact(text)
+}
+
+// scanConversation counts the secrets of each kind in a conversation and
+// its sub-agents
+func (r *redactor) scanConversation(conv Conversation) map[string]int {
+	counts := make(map[string]int)
+	scan := func(text string) {
+		for _, m := range r.find(text) {
+			counts[m.kind]++
+		}
+	}
+	scan(conv.Summary)
+	for _, msg := range conv.allMessages() {
+		scan(msg.Text)
+		if msg.Kind == kindToolUse && msg.Tool != nil {
+			scan(msg.Tool.Input)
+		}
+	}
+	return counts
+}
+
+// formatSecretCounts lists counts as "jwt×2, aws-access-key", most first
+func formatSecretCounts(counts map[string]int) (string, int) {
+	kinds := make([]string, 0, len(counts))
+	total := 0
+	for kind, n := range counts {
+		kinds = append(kinds, kind)
+		total += n
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = kind
+		if counts[kind] > 1 {
+			parts[i] += fmt.Sprintf("×%d", counts[kind])
+		}
+	}
+	return strings.Join(parts, ", "), total
+}
+
+// loadRedactor builds the redactor for a command from the config, or
+// returns nil (redacting nothing) when redaction is turned off
+func loadRedactor(enabled bool) (*redactor, error) {
+	if !enabled {
+		return nil, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newRedactor(cfg)
+}
+
+// runScanSecrets implements `ccs scan-secrets`: the sessions holding
+// credentials, and what kinds
+func runScanSecrets(args []string) error {
+	scan := defaultScanFlags()
+	query := ""
+	for _, arg := range args {
+		switch {
+		case scan.parse(arg):
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		default:
+			query = arg
+		}
+	}
+
+	r, err := loadRedactor(true)
+	if err != nil {
+		return err
+	}
+	items, err := scan.load(searchOptions{})
+	if err != nil {
+		return err
+	}
+	if items, err = filterItems(items, query); err != nil {
+		return err
+	}
+
+	format := "%-16s  %-22s  %-36s  %7s  %s\n"
+	fmt.Printf(format, "DATE", "PROJECT", "SESSION", "SECRETS", "KINDS")
+	sessions := 0
+	for _, item := range items {
+		counts := r.scanConversation(item.conv)
+		if len(counts) == 0 {
+			continue
+		}
+		kinds, total := formatSecretCounts(counts)
+		fmt.Printf(format, formatTimestamp(item.conv.LastTimestamp), truncate(filepath.Base(item.conv.Cwd), 22),
+			item.conv.SessionID, fmt.Sprint(total), kinds)
+		sessions++
+	}
+	fmt.Printf("\n%d of %d sessions contain secrets\n", sessions, len(items))
+	return nil
+}
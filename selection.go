@@ -0,0 +1,101 @@
+package main
+
+import "fmt"
+
+// toggleMark marks or unmarks the row under the cursor and moves down, so
+// holding Tab marks a run of rows
+func (m *model) toggleMark() {
+	if m.cursor >= len(m.filtered) {
+		return
+	}
+	id := m.filtered[m.cursor].conv.SessionID
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		if m.marked == nil {
+			m.marked = make(map[string]bool)
+		}
+		m.marked[id] = true
+	}
+	if m.cursor < len(m.filtered)-1 {
+		m.cursor++
+		m.previewScroll = 0
+	}
+}
+
+// markAll marks every row matching the search, or clears the marks when
+// they're all marked already
+func (m *model) markAll() {
+	if len(m.filtered) > 0 && len(m.markedItems()) == len(m.filtered) {
+		m.marked = nil
+		return
+	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	for _, item := range m.filtered {
+		m.marked[item.conv.SessionID] = true
+	}
+}
+
+// markedItems returns the marked rows matching the search. Marks on rows
+// the search hides are kept but not acted on.
+func (m model) markedItems() []listItem {
+	if len(m.marked) == 0 {
+		return nil
+	}
+	var items []listItem
+	for _, item := range m.filtered {
+		if m.marked[item.conv.SessionID] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// actionTargets returns the rows an action applies to: the marked ones, or
+// the one under the cursor when none are marked
+func (m model) actionTargets() []listItem {
+	if items := m.markedItems(); len(items) > 0 {
+		return items
+	}
+	if m.cursor < len(m.filtered) {
+		return []listItem{m.filtered[m.cursor]}
+	}
+	return nil
+}
+
+// deleteTargets returns the rows the pending delete applies to: the marked
+// ones, or the one the delete was started on
+func (m model) deleteTargets() []listItem {
+	if items := m.markedItems(); len(items) > 0 {
+		return items
+	}
+	if m.deleteIndex >= 0 && m.deleteIndex < len(m.filtered) {
+		return []listItem{m.filtered[m.deleteIndex]}
+	}
+	return nil
+}
+
+// withoutSessions returns items minus the given sessions, in place
+func withoutSessions(items []listItem, sessions map[string]bool) []listItem {
+	kept := items[:0]
+	for _, item := range items {
+		if !sessions[item.conv.SessionID] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// bulkFailures describes the failures of an action on total conversations,
+// e.g. "Delete failed for 2 of 40: permission denied", or "" if none failed
+func bulkFailures(action string, total int, failures []string) string {
+	switch {
+	case len(failures) == 0:
+		return ""
+	case total == 1:
+		return fmt.Sprintf("%s failed: %s", action, failures[0])
+	}
+	return fmt.Sprintf("%s failed for %d of %d: %s", action, len(failures), total, failures[0])
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMarkRows(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "s1", LastTimestamp: "2024-01-20T10:00:00Z", Messages: []Message{{Role: "user", Text: "fix the login page"}}},
+		{SessionID: "s2", LastTimestamp: "2024-01-19T10:00:00Z", Messages: []Message{{Role: "user", Text: "login bug"}}},
+		{SessionID: "s3", LastTimestamp: "2024-01-18T10:00:00Z", Messages: []Message{{Role: "user", Text: "deploy"}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	result, _ = result.(model).Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = result.(model)
+	if !m.marked["s1"] || !m.marked["s2"] || m.cursor != 2 {
+		t.Fatalf("Tab should mark and move down: marked %v, cursor %d", m.marked, m.cursor)
+	}
+	if !strings.Contains(m.formatListItem(m.filtered[0], false), "●") || strings.Contains(m.formatListItem(m.filtered[2], false), "●") {
+		t.Error("only marked rows should show the marker")
+	}
+
+	m.cursor = 1
+	m.toggleMark()
+	if m.marked["s2"] {
+		t.Error("Tab on a marked row should unmark it")
+	}
+
+	// Marks on rows the search hides aren't acted on
+	m.textInput.SetValue("deploy")
+	m.updateFilter()
+	if len(m.markedItems()) != 0 || len(m.actionTargets()) != 1 || m.actionTargets()[0].conv.SessionID != "s3" {
+		t.Errorf("targets = %+v", m.actionTargets())
+	}
+
+	m.textInput.SetValue("")
+	m.updateFilter()
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	m = result.(model)
+	if len(m.markedItems()) != 1 {
+		t.Errorf("Ctrl+A is left to the search field and shouldn't mark rows, got %v", m.marked)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}, Alt: true})
+	m = result.(model)
+	if len(m.markedItems()) != 3 {
+		t.Errorf("Alt+A should mark every row, got %v", m.marked)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}, Alt: true})
+	if len(result.(model).markedItems()) != 0 {
+		t.Error("Alt+A with every row marked should clear the marks")
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	var items []listItem
+	for _, id := range []string{"sess-1", "sess-2", "sess-3"} {
+		c := Conversation{SessionID: id, FilePath: filepath.Join(dir, id+".jsonl"), LastTimestamp: "2024-01-14T10:00:00Z"}
+		os.WriteFile(c.FilePath, []byte("session\n"), 0644)
+		items = append(items, listItem{conv: c})
+	}
+	// A file already deleted outside ccs: its directory doesn't exist
+	items = append(items, listItem{conv: Conversation{SessionID: "sess-4", FilePath: filepath.Join(dir, "gone", "sess-4.jsonl")}})
+	m := initialModel(items, "", nil)
+	m.width, m.height = 120, 40
+	m.markAll()
+	delete(m.marked, "sess-2")
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if view := result.(model).View(); !strings.Contains(view, "Delete 3 marked conversations?") {
+		t.Errorf("expected a combined prompt in:\n%s", view)
+	}
+	result, _ = result.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = result.(model)
+	if len(m.items) != 1 || m.items[0].conv.SessionID != "sess-2" {
+		t.Fatalf("items left = %+v", m.items)
+	}
+	if m.errorMsg != "" {
+		t.Errorf("a file that is already gone should not be an error, got %q", m.errorMsg)
+	}
+	if !strings.Contains(m.statusMsg, "Moved 2 conversations") || len(m.marked) != 0 {
+		t.Errorf("status = %q, marked = %v", m.statusMsg, m.marked)
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	m = result.(model)
+	if len(m.items) != 3 || m.statusMsg != "Restored 2 conversations" {
+		t.Errorf("undo should restore the whole batch, got %d items, status %q", len(m.items), m.statusMsg)
+	}
+	if _, err := os.Stat(items[0].conv.FilePath); err != nil {
+		t.Error("undo should restore the session file")
+	}
+}
+
+func TestBulkExport(t *testing.T) {
+	dir := t.TempDir()
+	old, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(old)
+
+	items := buildItems([]Conversation{
+		{SessionID: "s1", Cwd: "/src/web", Messages: []Message{{Role: "user", Text: "fix the login page"}}},
+		{SessionID: "s2", Cwd: "/src/api", Messages: []Message{{Role: "user", Text: "login bug"}}},
+		{SessionID: "s3", Cwd: "/src/api", Messages: []Message{{Role: "user", Text: "deploy"}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+	m.markAll()
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}, Alt: true})
+	m = result.(model)
+	if m.errorMsg != "" || m.statusMsg != "Exported 3 conversations to the current directory" {
+		t.Fatalf("status = %q, error = %q", m.statusMsg, m.errorMsg)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(files) != 3 {
+		t.Errorf("exported %v", files)
+	}
+}
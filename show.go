@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// findSessionFile resolves a session argument: a path to a session file,
+// or a full or unique prefix of a session ID
+func findSessionFile(ref string) (string, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return ref, nil
+	}
+	files, err := listAllConversationFiles()
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, path := range files {
+		if isAgentFile(path) {
+			continue
+		}
+		id := sessionFileID(path)
+		if id == ref {
+			return path, nil
+		}
+		if strings.HasPrefix(id, ref) {
+			matches = append(matches, path)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no session matches %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Strings(matches)
+	ids := make([]string, len(matches))
+	for i, path := range matches {
+		ids[i] = sessionFileID(path)
+	}
+	return "", fmt.Errorf("session ID %q is ambiguous: %s", ref, strings.Join(ids, ", "))
+}
+
+// loadSession reads the conversation a session ID, prefix or path refers to
+func loadSession(ref string) (*Conversation, error) {
+	path, err := findSessionFile(ref)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := parseConversationFile(path, time.Time{}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, fmt.Errorf("%s has no messages", path)
+	}
+	return conv, nil
+}
+
+// transcriptMessages returns the messages of one branch (0 is the active
+// one), leaving out tool calls and thinking unless asked for
+func transcriptMessages(conv Conversation, branch int, tools, thinking bool) []Message {
+	branches := conv.branches()
+	var messages []Message
+	for _, i := range branches[branch%len(branches)] {
+		msg := conv.Messages[i]
+		if (msg.Kind == kindToolUse || msg.Kind == kindToolResult) && !tools {
+			continue
+		}
+		if msg.Kind == kindThinking && !thinking {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// renderTranscript renders messages in full. With a non-empty matcher only
+// matching messages are shown, with up to context messages either side.
+func renderTranscript(messages []Message, mt matcher, context int) []string {
+	show := make(map[int]bool)
+	matched := make(map[int]bool)
+	for i, msg := range messages {
+		if mt.empty() || msg.Kind == kindCompaction {
+			show[i] = true
+		} else if mt.matchMessage(msg) {
+			matched[i] = true
+			for j := max(0, i-context); j <= min(len(messages)-1, i+context); j++ {
+				show[j] = true
+			}
+		}
+	}
+	if !mt.empty() && len(matched) == 0 {
+		return []string{"\033[90mNo messages match\033[0m"}
+	}
+
+	var lines []string
+	lastShown := -1
+	for i, msg := range messages {
+		if !show[i] {
+			continue
+		}
+		if skipped := i - lastShown - 1; skipped > 0 {
+			lines = append(lines, fmt.Sprintf("\033[90m    ... %d messages ...\033[0m", skipped), "")
+		}
+		lastShown = i
+
+		ts := formatTimestamp(msg.Ts)
+		if msg.Kind == kindCompaction {
+			lines = append(lines, fmt.Sprintf("\033[33m──── %s %s ────\033[0m", ts, msg.Text), "") // Yellow
+			continue
+		}
+		label, color := messageLabel(msg)
+		if matched[i] {
+			lines = append(lines, fmt.Sprintf("\033[1;%sm>>> %s %s:\033[0m", color, ts, label)) // Bold
+		} else {
+			lines = append(lines, fmt.Sprintf("\033[1;%sm%s %s:\033[0m", color, ts, label))
+		}
+		text := msg.Text
+		if msg.Kind == kindToolUse {
+			text = toolSummary(msg.Tool)
+		}
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, "    "+mt.highlight(line))
+		}
+		lines = append(lines, "")
+	}
+	if skipped := len(messages) - lastShown - 1; skipped > 0 && lastShown >= 0 {
+		lines = append(lines, fmt.Sprintf("\033[90m    ... %d more messages\033[0m", skipped))
+	}
+	return lines
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// useColor reports whether stdout is a terminal that should get colours
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runShow implements `ccs show`: one conversation's transcript in full
+func runShow(args []string) error {
+	var ref, grep string
+	tools, thinking, redact := false, false, true
+	context := 2
+	for _, arg := range args {
+		switch {
+		case arg == "--no-redact":
+			redact = false
+		case arg == "--tools":
+			tools = true
+		case arg == "--thinking":
+			thinking = true
+		case strings.HasPrefix(arg, "--grep="):
+			grep = strings.TrimPrefix(arg, "--grep=")
+		case strings.HasPrefix(arg, "--context="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--context="))
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --context value %q", strings.TrimPrefix(arg, "--context="))
+			}
+			context = n
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		case ref != "":
+			return fmt.Errorf("expected one session, got %q and %q", ref, arg)
+		default:
+			ref = arg
+		}
+	}
+	if ref == "" {
+		return fmt.Errorf("usage: ccs show <session-id|file> [--tools] [--thinking] [--grep=QUERY] [--context=N] [--no-redact]")
+	}
+
+	mt, err := newMatcher(grep, modeExact)
+	if err != nil {
+		return err
+	}
+	mt.search = searchOptions{IncludeThinking: thinking}
+	r, err := loadRedactor(redact)
+	if err != nil {
+		return err
+	}
+	conv, err := loadSession(ref)
+	if err != nil {
+		return err
+	}
+	*conv = r.conversation(*conv)
+
+	lines := []string{
+		"\033[1;33mProject:\033[0m " + conv.Cwd,
+		"\033[1;33mSession:\033[0m " + conv.SessionID,
+	}
+	if meta := metadataLine(*conv); meta != "" {
+		lines = append(lines, meta)
+	}
+	lines = append(lines, "")
+	lines = append(lines, renderTranscript(transcriptMessages(*conv, 0, tools, thinking), mt, context)...)
+
+	out := strings.Join(lines, "\n")
+	if !useColor() {
+		out = ansiPattern.ReplaceAllString(out, "")
+	}
+	fmt.Println(strings.TrimRight(out, "\n"))
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindSessionFile(t *testing.T) {
+	dir := setupIndexTest(t)
+	project := filepath.Join(dir, "-src-api")
+	os.MkdirAll(project, 0755)
+	for _, name := range []string{"abc123.jsonl", "abd456.jsonl", "agent-abc.jsonl"} {
+		os.WriteFile(filepath.Join(project, name), []byte("{}\n"), 0644)
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{"abc123", "abc123.jsonl", ""},
+		{"abc", "abc123.jsonl", ""}, // The agent file doesn't count
+		{"ab", "", "ambiguous: abc123, abd456"},
+		{"xyz", "", "no session matches"},
+		{filepath.Join(project, "abd456.jsonl"), "abd456.jsonl", ""},
+	}
+	for _, tt := range tests {
+		path, err := findSessionFile(tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("findSessionFile(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || filepath.Base(path) != tt.want {
+			t.Errorf("findSessionFile(%q) = %q, %v; want %s", tt.ref, path, err, tt.want)
+		}
+	}
+}
+
+func TestRenderTranscript(t *testing.T) {
+	long := strings.Repeat("x", 800)
+	conv := Conversation{Messages: []Message{
+		{Role: "user", Text: "first question"},
+		{Role: "assistant", Text: long},
+		{Role: "assistant", Kind: kindThinking, Text: "pondering"},
+		{Role: "assistant", Kind: kindToolUse, Text: "Bash ls", Tool: &ToolCall{Name: "Bash", Input: `{"command":"ls"}`}},
+		{Role: "user", Text: "second question"},
+		{Role: "assistant", Text: "answer"},
+	}}
+
+	out := ansiPattern.ReplaceAllString(strings.Join(renderTranscript(transcriptMessages(conv, 0, false, false), matcher{}, 2), "\n"), "")
+	if !strings.Contains(out, long) {
+		t.Error("messages should not be truncated")
+	}
+	if strings.Contains(out, "pondering") || strings.Contains(out, "Tool Bash") {
+		t.Errorf("thinking and tools should be hidden by default:\n%s", out)
+	}
+
+	out = ansiPattern.ReplaceAllString(strings.Join(renderTranscript(transcriptMessages(conv, 0, true, true), matcher{}, 2), "\n"), "")
+	if !strings.Contains(out, "Thinking:") || !strings.Contains(out, "Tool Bash:\n    ls") {
+		t.Errorf("--tools and --thinking should show them:\n%s", out)
+	}
+
+	mt, _ := newMatcher("second", modeExact)
+	out = ansiPattern.ReplaceAllString(strings.Join(renderTranscript(transcriptMessages(conv, 0, false, false), mt, 0), "\n"), "")
+	if !strings.Contains(out, ">>> ") || strings.Contains(out, "first question") || strings.Contains(out, "answer") {
+		t.Errorf("--grep with no context should show only the match:\n%s", out)
+	}
+	if !strings.Contains(out, "... 2 messages ...") || !strings.Contains(out, "... 1 more messages") {
+		t.Errorf("skipped messages should be summarised:\n%s", out)
+	}
+
+	out = ansiPattern.ReplaceAllString(strings.Join(renderTranscript(transcriptMessages(conv, 0, false, false), mt, 1), "\n"), "")
+	if strings.Contains(out, "first question") || !strings.Contains(out, "answer") || !strings.Contains(out, long) {
+		t.Errorf("--context=1 should add one message either side:\n%s", out)
+	}
+
+	mt, _ = newMatcher("nowhere", modeExact)
+	if out := renderTranscript(conv.Messages, mt, 2); len(out) != 1 || !strings.Contains(out[0], "No messages match") {
+		t.Errorf("no matches = %q", out)
+	}
+}
+
+func TestRunShowBadArgs(t *testing.T) {
+	for _, args := range [][]string{nil, {"a", "b"}, {"a", "--context=x"}, {"a", "--wat"}} {
+		if err := runShow(args); err == nil {
+			t.Errorf("runShow(%v) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// statsGroupings are the ways token usage can be grouped, each mapping a
+// response to its group
+var statsGroupings = map[string]func(conv Conversation, rec usageRecord) string{
+	"project": func(conv Conversation, rec usageRecord) string {
+		return filepath.Base(conv.Cwd)
+	},
+	"day": func(conv Conversation, rec usageRecord) string {
+		t, ok := parseTimestamp(rec.Ts)
+		if !ok {
+			return "unknown"
+		}
+		return t.Local().Format("2006-01-02")
+	},
+	"week": func(conv Conversation, rec usageRecord) string {
+		t, ok := parseTimestamp(rec.Ts)
+		if !ok {
+			return "unknown"
+		}
+		year, week := t.Local().ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	},
+	"model": func(conv Conversation, rec usageRecord) string {
+		if rec.Model == "" {
+			return "unknown"
+		}
+		return rec.Model
+	},
+}
+
+// statsRow is the usage of one group
+type statsRow struct {
+	Key      string
+	Sessions int
+	Usage    tokenUsage
+	Cost     float64 // Estimated USD
+	Unpriced bool    // Some usage is for models without a price
+}
+
+// computeStats totals the usage of items, sub-agents included, by the
+// given grouping. Projects and models are ordered by tokens used, days and
+// weeks newest first.
+func computeStats(items []listItem, by string, cfg config) []statsRow {
+	group := statsGroupings[by]
+	rows := make(map[string]*statsRow)
+	sessions := make(map[string]map[string]bool)
+
+	for _, item := range items {
+		conv := item.conv
+		logs := [][]usageRecord{conv.UsageLog}
+		for _, agent := range conv.Agents {
+			logs = append(logs, agent.UsageLog)
+		}
+		for _, log := range logs {
+			for _, rec := range log {
+				key := group(conv, rec)
+				row, ok := rows[key]
+				if !ok {
+					row = &statsRow{Key: key}
+					rows[key] = row
+					sessions[key] = make(map[string]bool)
+				}
+				row.Usage = row.Usage.add(rec.Usage)
+				if price, ok := cfg.price(rec.Model); ok {
+					row.Cost += price.cost(rec.Usage)
+				} else {
+					row.Unpriced = true
+				}
+				sessions[key][conv.SessionID] = true
+			}
+		}
+	}
+
+	result := make([]statsRow, 0, len(rows))
+	for key, row := range rows {
+		row.Sessions = len(sessions[key])
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if by == "day" || by == "week" {
+			return result[i].Key > result[j].Key
+		}
+		if a, b := result[i].Usage.total(), result[j].Usage.total(); a != b {
+			return a > b
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// formatCost formats an estimate, flagging ones that miss unpriced models
+func formatCost(cost float64, unpriced bool) string {
+	s := fmt.Sprintf("$%.2f", cost)
+	if unpriced {
+		s += "*"
+	}
+	return s
+}
+
+// formatStats renders rows as a table with a total line, showing at most
+// limit rows (0 means all)
+func formatStats(rows []statsRow, by string, limit int) []string {
+	format := "%-28s  %8s  %7s  %7s  %7s  %7s  %7s  %10s"
+	lines := []string{fmt.Sprintf(format, strings.ToUpper(by), "SESSIONS", "INPUT", "OUTPUT", "C.WRITE", "C.READ", "TOTAL", "COST")}
+
+	var total statsRow
+	for i, row := range rows {
+		total.Usage = total.Usage.add(row.Usage)
+		total.Cost += row.Cost
+		total.Unpriced = total.Unpriced || row.Unpriced
+		if limit > 0 && i >= limit {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(format, truncate(row.Key, 28), fmt.Sprint(row.Sessions),
+			formatTokens(row.Usage.Input), formatTokens(row.Usage.Output),
+			formatTokens(row.Usage.CacheCreation), formatTokens(row.Usage.CacheRead),
+			formatTokens(row.Usage.total()), formatCost(row.Cost, row.Unpriced)))
+	}
+	if limit > 0 && len(rows) > limit {
+		lines = append(lines, fmt.Sprintf("... %d more", len(rows)-limit))
+	}
+	lines = append(lines, fmt.Sprintf(format, "TOTAL", "",
+		formatTokens(total.Usage.Input), formatTokens(total.Usage.Output),
+		formatTokens(total.Usage.CacheCreation), formatTokens(total.Usage.CacheRead),
+		formatTokens(total.Usage.total()), formatCost(total.Cost, total.Unpriced)))
+	if total.Unpriced {
+		lines = append(lines, "* excludes models without a price; add them to "+configPath())
+	}
+	return lines
+}
+
+// runStats implements `ccs stats`: token usage and estimated cost
+func runStats(args []string) error {
+	scan := defaultScanFlags()
+	groupings := []string{"project"}
+	query := ""
+	for _, arg := range args {
+		switch {
+		case scan.parse(arg):
+		case strings.HasPrefix(arg, "--by="):
+			groupings = strings.Split(strings.TrimPrefix(arg, "--by="), ",")
+			for _, by := range groupings {
+				if statsGroupings[by] == nil {
+					return fmt.Errorf("invalid --by value %q (want project, day, week or model)", by)
+				}
+			}
+		case strings.HasPrefix(arg, "-"):
+			return fmt.Errorf("unknown flag %s", arg)
+		default:
+			query = arg
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	items, err := scan.load(searchOptions{})
+	if err != nil {
+		return err
+	}
+	if items, err = filterItems(items, query); err != nil {
+		return err
+	}
+
+	for i, by := range groupings {
+		if i > 0 {
+			fmt.Println()
+		}
+		for _, line := range formatStats(computeStats(items, by, cfg), by, 0) {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// renderStats is the TUI stats pane: usage of the listed conversations by
+// project, week and model
+func (m model) renderStats(height int) string {
+	lines := []string{fmt.Sprintf("\033[1;33mToken usage of %d listed conversations\033[0m (estimated cost)", len(m.filtered)), ""}
+	for _, by := range []string{"project", "week", "model"} {
+		table := formatStats(computeStats(m.filtered, by, m.config), by, 8)
+		lines = append(lines, "\033[90m"+table[0]+"\033[0m")
+		lines = append(lines, table[1:]...)
+		lines = append(lines, "")
+	}
+
+	if m.previewScroll >= len(lines) {
+		m.previewScroll = max(0, len(lines)-1)
+	}
+	end := min(m.previewScroll+max(1, height), len(lines))
+	return strings.Join(lines[m.previewScroll:end], "\n")
+}
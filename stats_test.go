@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestComputeStats(t *testing.T) {
+	usage := func(in, out int) tokenUsage { return tokenUsage{Input: in, Output: out} }
+	items := buildItems([]Conversation{
+		{
+			SessionID: "s1",
+			Cwd:       "/src/api",
+			UsageLog: []usageRecord{
+				{Model: "claude-sonnet-4-5", Ts: "2024-01-17T12:00:00Z", Usage: usage(1000, 500)},
+				{Model: "claude-opus-4-1", Ts: "2024-01-24T12:00:00Z", Usage: usage(2000, 1000)},
+			},
+			Agents: []Conversation{{
+				SessionID: "agent-1",
+				UsageLog:  []usageRecord{{Model: "claude-haiku-4-5", Ts: "2024-01-24T13:00:00Z", Usage: usage(100, 100)}},
+			}},
+		},
+		{
+			SessionID: "s2",
+			Cwd:       "/src/web",
+			UsageLog:  []usageRecord{{Model: "mystery-model", Ts: "2024-01-18T12:00:00Z", Usage: usage(10, 10)}},
+		},
+	}, searchOptions{})
+
+	rows := computeStats(items, "project", config{})
+	if len(rows) != 2 || rows[0].Key != "api" || rows[1].Key != "web" {
+		t.Fatalf("project rows = %+v", rows)
+	}
+	if rows[0].Usage.total() != 4700 || rows[0].Sessions != 1 {
+		t.Errorf("api usage = %d over %d sessions, want 4700 over 1 (agents included)", rows[0].Usage.total(), rows[0].Sessions)
+	}
+	// 1000*3 + 500*15 + 2000*15 + 1000*75 + 100*1 + 100*5, per million
+	if rows[0].Cost < 0.1160 || rows[0].Cost > 0.1162 || rows[0].Unpriced {
+		t.Errorf("api cost = %v (unpriced %v), want 0.1161", rows[0].Cost, rows[0].Unpriced)
+	}
+	if !rows[1].Unpriced {
+		t.Error("web uses a model without a price")
+	}
+
+	weeks := computeStats(items, "week", config{})
+	if len(weeks) != 2 || weeks[0].Key != "2024-W04" || weeks[1].Key != "2024-W03" {
+		t.Errorf("weeks should be newest first, got %+v", weeks)
+	}
+
+	models := computeStats(items, "model", config{Prices: map[string]modelPrice{"mystery": {Input: 1}}})
+	if len(models) != 4 || models[0].Key != "claude-opus-4-1" {
+		t.Errorf("models should be ordered by usage, got %+v", models)
+	}
+	for _, row := range models {
+		if row.Unpriced {
+			t.Errorf("%s should be priced from the config", row.Key)
+		}
+	}
+}
+
+func TestFormatStats(t *testing.T) {
+	rows := []statsRow{
+		{Key: "api", Sessions: 1, Usage: tokenUsage{Input: 3000, Output: 1500}, Cost: 0.0315},
+		{Key: "web", Sessions: 1, Usage: tokenUsage{Input: 10, Output: 10}, Unpriced: true},
+	}
+	lines := formatStats(rows, "project", 1)
+
+	if !strings.HasPrefix(lines[0], "PROJECT") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "api") || lines[2] != "... 1 more" {
+		t.Errorf("limit should keep the top row, got %q", lines[1:3])
+	}
+	if !strings.HasPrefix(lines[3], "TOTAL") || !strings.HasSuffix(lines[3], "*") {
+		t.Errorf("total should cover all rows and flag unpriced usage, got %q", lines[3])
+	}
+	if !strings.HasPrefix(lines[4], "* excludes") {
+		t.Errorf("expected a footnote, got %q", lines[4])
+	}
+}
+
+func TestRunStatsRejectsBadFlags(t *testing.T) {
+	if err := runStats([]string{"--by=month"}); err == nil {
+		t.Error("invalid --by should be an error")
+	}
+	if err := runStats([]string{"--bogus"}); err == nil {
+		t.Error("unknown flag should be an error")
+	}
+}
+
+func TestStatsPaneToggle(t *testing.T) {
+	items := buildItems([]Conversation{
+		{SessionID: "s1", Cwd: "/src/api", UsageLog: []usageRecord{{Model: "claude-sonnet-4-5", Ts: "2024-01-24T12:00:00Z", Usage: tokenUsage{Input: 1000}}}},
+		{SessionID: "s2", Cwd: "/src/web", UsageLog: []usageRecord{{Model: "claude-sonnet-4-5", Ts: "2024-01-25T12:00:00Z", Usage: tokenUsage{Output: 500}}}},
+	}, searchOptions{})
+	m := initialModel(items, "", nil)
+	m.width, m.height = 120, 40
+
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	m = newM.(model)
+	view := m.View()
+	if !strings.Contains(view, "Token usage of 2 listed conversations") || !strings.Contains(view, "2024-W04") {
+		t.Errorf("ctrl+g should show the stats pane, got %q", view)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// Message kinds besides plain text
+const (
+	kindToolUse    = "tool_use"
+	kindToolResult = "tool_result"
+	kindThinking   = "thinking"
+
+	kindCompaction     = "compaction"      // Marker where the context was compacted
+	kindCompactSummary = "compact_summary" // Synthetic prompt summarising the compacted part
+)
+
+// maxToolResultLen caps how much of a tool's output is kept, so huge file
+// reads don't bloat the index; failures and commands are near the start.
+// It caps a tool call's input too, which can carry a whole file to write.
+const maxToolResultLen = 8192
+
+// maxToolInputValueLen caps each string in an oversized tool input, so the
+// file path and command survive while file contents are cut short
+const maxToolInputValueLen = 1024
+
+// ToolCall describes a tool invocation or its result
+type ToolCall struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Input   string `json:"input,omitempty"`    // Raw JSON input (tool_use)
+	Path    string `json:"path,omitempty"`     // File the tool operated on, if any
+	IsError bool   `json:"is_error,omitempty"` // Result reported an error (tool_result)
+}
+
+// ContentBlock is one element of a message content array
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	Thinking  string          `json:"thinking"`    // thinking
+	ID        string          `json:"id"`          // tool_use
+	Name      string          `json:"name"`        // tool_use
+	Input     json.RawMessage `json:"input"`       // tool_use
+	ToolUseID string          `json:"tool_use_id"` // tool_result
+	Content   json.RawMessage `json:"content"`     // tool_result: string or blocks
+	IsError   bool            `json:"is_error"`    // tool_result
+}
+
+// decodeContent splits message content into its text and its blocks.
+// Content is either a plain string or an array of blocks.
+func decodeContent(content json.RawMessage) (string, []ContentBlock) {
+	if len(content) == 0 {
+		return "", nil
+	}
+
+	var str string
+	if err := json.Unmarshal(content, &str); err == nil {
+		return str, nil
+	}
+
+	var blocks []ContentBlock
+	if err := json.Unmarshal(content, &blocks); err == nil {
+		var parts []string
+		for _, block := range blocks {
+			if block.Type == "text" && block.Text != "" {
+				parts = append(parts, block.Text)
+			}
+		}
+		return strings.Join(parts, " "), blocks
+	}
+
+	return "", nil
+}
+
+// toolMessages converts the tool_use and tool_result blocks of one line
+// into messages. Results are named after the call they answer, looked up
+// in the messages parsed so far.
+func (conv *Conversation) toolMessages(blocks []ContentBlock, ts string) []Message {
+	var msgs []Message
+	for _, block := range blocks {
+		switch block.Type {
+		case kindToolUse:
+			input := capToolInput(compactJSON(block.Input))
+			msgs = append(msgs, Message{
+				Role: "tool",
+				Kind: kindToolUse,
+				Text: block.Name + " " + input,
+				Ts:   ts,
+				Tool: &ToolCall{
+					ID:    block.ID,
+					Name:  block.Name,
+					Input: input,
+					Path:  toolInputPath(block.Input),
+				},
+			})
+		case kindToolResult:
+			call := ToolCall{ID: block.ToolUseID, IsError: block.IsError}
+			if use := conv.findToolUse(block.ToolUseID); use != nil {
+				call.Name, call.Path = use.Name, use.Path
+			}
+			text, _ := decodeContent(block.Content)
+			text = capText(text, maxToolResultLen)
+			msgs = append(msgs, Message{
+				Role: "tool",
+				Kind: kindToolResult,
+				Text: text,
+				Ts:   ts,
+				Tool: &call,
+			})
+		}
+	}
+	return msgs
+}
+
+// thinkingMessages converts the thinking blocks of one line into messages
+func thinkingMessages(blocks []ContentBlock, ts string) []Message {
+	var msgs []Message
+	for _, block := range blocks {
+		if block.Type == kindThinking && strings.TrimSpace(block.Thinking) != "" {
+			msgs = append(msgs, Message{
+				Role: "thinking",
+				Kind: kindThinking,
+				Text: block.Thinking,
+				Ts:   ts,
+			})
+		}
+	}
+	return msgs
+}
+
+// withoutThinking returns msgs minus any thinking messages
+func withoutThinking(msgs []Message) []Message {
+	kept := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Kind != kindThinking {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
+
+// findToolUse returns the tool call with the given ID. Results normally
+// follow their call closely, so search backwards.
+func (conv *Conversation) findToolUse(id string) *ToolCall {
+	if id == "" {
+		return nil
+	}
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		msg := conv.Messages[i]
+		if msg.Kind == kindToolUse && msg.Tool.ID == id {
+			return msg.Tool
+		}
+	}
+	return nil
+}
+
+// toolInputPath extracts the file or directory a tool call operates on
+func toolInputPath(input json.RawMessage) string {
+	var fields struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Path         string `json:"path"`
+	}
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return ""
+	}
+	for _, p := range []string{fields.FilePath, fields.NotebookPath, fields.Path} {
+		if p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// toolSummary is a one-line description of a tool call for display, e.g.
+// the command for Bash or the file for Edit
+func toolSummary(call *ToolCall) string {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(call.Input), &fields); err == nil {
+		for _, key := range []string{"command", "file_path", "notebook_path", "pattern", "url", "query", "description", "prompt"} {
+			if v, ok := fields[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return call.Input
+}
+
+// capText cuts s to at most n bytes, on a rune boundary
+func capText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// capToolInput shortens an oversized tool input. Long strings are cut
+// inside the JSON so it still parses for summaries and exports; only if
+// that isn't enough is the JSON itself cut.
+func capToolInput(input string) string {
+	if len(input) <= maxToolResultLen {
+		return input
+	}
+	var fields any
+	if err := json.Unmarshal([]byte(input), &fields); err == nil {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(capStrings(fields)); err == nil {
+			input = strings.TrimSuffix(buf.String(), "\n")
+		}
+	}
+	return capText(input, maxToolResultLen)
+}
+
+// capStrings cuts the long strings in a decoded JSON value
+func capStrings(v any) any {
+	switch v := v.(type) {
+	case string:
+		if len(v) > maxToolInputValueLen {
+			return capText(v, maxToolInputValueLen) + "…"
+		}
+	case map[string]any:
+		for k, item := range v {
+			v[k] = capStrings(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = capStrings(item)
+		}
+	}
+	return v
+}
+
+// compactJSON re-encodes JSON without insignificant whitespace
+func compactJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// chatMessageCount counts user and assistant text messages, leaving out
+// tool traffic and compaction summaries
+func (conv Conversation) chatMessageCount() int {
+	n := 0
+	for _, msg := range conv.Messages {
+		if (msg.Role == "user" || msg.Role == "assistant") && msg.Kind == "" {
+			n++
+		}
+	}
+	return n
+}
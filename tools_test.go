@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var toolSession = []string{
+	`{"type":"user","cwd":"/src/app","message":{"content":"why do the pods crash?"},"timestamp":"2024-01-15T10:00:00Z"}`,
+	`{"type":"assistant","message":{"content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"kubectl get pods","description":"List pods"}}]},"timestamp":"2024-01-15T10:01:00Z"}`,
+	`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"error: connection refused","is_error":true}]},"timestamp":"2024-01-15T10:02:00Z"}`,
+	`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"toolu_2","name":"Edit","input":{"file_path":"internal/auth/handler.go","old_string":"a","new_string":"b"}}]},"timestamp":"2024-01-15T10:03:00Z"}`,
+	`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"File updated"}]}]},"timestamp":"2024-01-15T10:04:00Z"}`,
+	`{"type":"assistant","message":{"content":"Fixed the handler."},"timestamp":"2024-01-15T10:05:00Z"}`,
+}
+
+var thinkingSession = []string{
+	`{"type":"user","cwd":"/src/app","message":{"content":"fix the flaky test"},"timestamp":"2024-01-15T10:00:00Z"}`,
+	`{"type":"assistant","message":{"content":[{"type":"thinking","thinking":"Probably a race on the shared cache.","signature":"abc"},{"type":"text","text":"Looking at the test."}]},"timestamp":"2024-01-15T10:01:00Z"}`,
+}
+
+func TestParseMessageKinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		kinds string
+		chat  int
+	}{
+		{"tools", toolSession, "user/ assistant/ tool/tool_use tool/tool_result tool/tool_use tool/tool_result assistant/", 3},
+		{"thinking", thinkingSession, "user/ thinking/thinking assistant/", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := parseJSONLFixture(t, tt.lines...)
+			if got := messageKinds(conv); got != tt.kinds {
+				t.Errorf("message kinds = %q, want %q", got, tt.kinds)
+			}
+			if got := conv.chatMessageCount(); got != tt.chat {
+				t.Errorf("chatMessageCount = %d, want %d", got, tt.chat)
+			}
+		})
+	}
+}
+
+func TestParseToolBlocks(t *testing.T) {
+	conv := parseJSONLFixture(t, toolSession...)
+
+	tests := []struct {
+		index int
+		name  string
+		path  string
+		text  string
+		isErr bool
+	}{
+		{2, "Bash", "", "kubectl get pods", false},
+		{3, "Bash", "", "error: connection refused", true}, // Named after its call
+		{4, "Edit", "internal/auth/handler.go", "old_string", false},
+		{5, "Edit", "internal/auth/handler.go", "File updated", false}, // Block-array content
+	}
+	for _, tt := range tests {
+		msg := conv.Messages[tt.index]
+		if msg.Tool == nil {
+			t.Errorf("message %d has no tool call", tt.index)
+			continue
+		}
+		if msg.Tool.Name != tt.name || msg.Tool.Path != tt.path || !strings.Contains(msg.Text, tt.text) || msg.Tool.IsError != tt.isErr {
+			t.Errorf("message %d = %q %+v, want %s %q containing %q, error=%v", tt.index, msg.Text, msg.Tool, tt.name, tt.path, tt.text, tt.isErr)
+		}
+	}
+	if getTopic(*conv) != "why do the pods crash?" {
+		t.Errorf("topic should ignore tool messages, got %q", getTopic(*conv))
+	}
+}
+
+func TestToolInputIsCapped(t *testing.T) {
+	content := strings.Repeat("package main\\n", 10000)
+	conv := parseJSONLFixture(t,
+		`{"type":"user","cwd":"/src/app","message":{"content":"write the file"},"timestamp":"2024-01-15T10:00:00Z"}`,
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Write","input":{"file_path":"main.go","content":"`+content+`"}}]},"timestamp":"2024-01-15T10:01:00Z"}`,
+	)
+	msg := conv.Messages[1]
+	if len(msg.Tool.Input) > maxToolResultLen || len(msg.Text) > maxToolResultLen+len("Write ") {
+		t.Errorf("input kept %d bytes, text %d, want at most %d", len(msg.Tool.Input), len(msg.Text), maxToolResultLen)
+	}
+	if !json.Valid([]byte(msg.Tool.Input)) || toolSummary(msg.Tool) != "main.go" {
+		t.Errorf("a capped input should still parse, got summary %q", toolSummary(msg.Tool))
+	}
+}
+
+func TestToolSummary(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"command":"go test ./...","description":"Run tests"}`, "go test ./..."},
+		{`{"file_path":"main.go"}`, "main.go"},
+		{`{"pattern":"TODO","path":"."}`, "TODO"},
+		{`{"other":1}`, `{"other":1}`},
+	}
+	for _, tt := range tests {
+		if got := toolSummary(&ToolCall{Input: tt.input}); got != tt.want {
+			t.Errorf("toolSummary(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToolQueries(t *testing.T) {
+	conv := parseJSONLFixture(t, toolSession...)
+	item := buildItems([]Conversation{*conv}, searchOptions{})[0]
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"kubectl", true},
+		{"connection refused", true},
+		{"tool:Bash", true},
+		{"tool:bash kubectl", true},
+		{"tool:Edit kubectl", false},
+		{"tool:Read", false},
+		{"file:internal/auth/handler.go", true},
+		{"file:handler.go tool:Edit", true},
+		{"file:other.go", false},
+		{"role:user kubectl", false},
+		{"role:tool kubectl", true},
+	}
+	for _, tt := range tests {
+		mt, err := newMatcher(tt.query, modeExact)
+		if err != nil {
+			t.Fatalf("newMatcher(%q) failed: %v", tt.query, err)
+		}
+		if _, ok := mt.matchItem(item); ok != tt.want {
+			t.Errorf("query %q matched = %v, want %v", tt.query, ok, tt.want)
+		}
+	}
+
+	// User scope leaves tool traffic out of the search text
+	userItem := buildItems([]Conversation{*conv}, searchOptions{Scope: scopeUser})[0]
+	if strings.Contains(userItem.searchText, "kubectl") {
+		t.Error("user scope should not search tool calls")
+	}
+}
+
+func TestRenderPreviewToolMessages(t *testing.T) {
+	conv := parseJSONLFixture(t, toolSession...)
+	item := listItem{conv: *conv}
+	m := initialModel([]listItem{item}, "kubectl", nil)
+
+	preview := m.renderPreview(item, 100)
+	if !strings.Contains(preview, "Tool Bash:") {
+		t.Errorf("preview should label tool calls, got %q", preview)
+	}
+	if !strings.Contains(preview, "Error Bash:") {
+		t.Errorf("preview should mark failed tool results, got %q", preview)
+	}
+	if !strings.Contains(preview, "\033[35m") || !strings.Contains(preview, "\033[1;35m>>>") {
+		t.Error("tool messages should use the tool style, with matches in bold")
+	}
+}
+
+func TestThinkingBlocks(t *testing.T) {
+	conv := parseJSONLFixture(t, thinkingSession...)
+
+	tests := []struct {
+		opts searchOptions
+		want bool
+	}{
+		{searchOptions{}, false},
+		{searchOptions{IncludeThinking: true}, true},
+		{searchOptions{Scope: scopeAssistant, IncludeThinking: true}, true},
+		{searchOptions{Scope: scopeUser, IncludeThinking: true}, false},
+	}
+	for _, tt := range tests {
+		item := buildItems([]Conversation{*conv}, tt.opts)[0]
+		if got := strings.Contains(item.searchText, "shared cache"); got != tt.want {
+			t.Errorf("%+v: thinking searchable = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+
+	// role:thinking searches thinking blocks regardless of the options
+	item := buildItems([]Conversation{*conv}, searchOptions{})[0]
+	mt, err := newMatcher("role:thinking race", modeExact)
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+	if _, ok := mt.matchItem(item); !ok {
+		t.Error("role:thinking should match thinking text")
+	}
+}
+
+func TestRenderPreviewThinkingToggle(t *testing.T) {
+	conv := parseJSONLFixture(t, thinkingSession...)
+	item := listItem{conv: *conv}
+	m := initialModel([]listItem{item}, "", nil)
+
+	if preview := m.renderPreview(item, 100); strings.Contains(preview, "shared cache") {
+		t.Errorf("thinking should be hidden by default, got %q", preview)
+	}
+
+	newM, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = newM.(model)
+	preview := m.renderPreview(item, 100)
+	if !strings.Contains(preview, "Thinking:") || !strings.Contains(preview, "shared cache") {
+		t.Errorf("ctrl+t should show thinking, got %q", preview)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getTrashDir returns the directory deleted conversations are moved to
+// Declared as a variable so it can be overridden in tests
+var getTrashDir = func() string {
+	return filepath.Join(getDataDir(), "trash")
+}
+
+// trashMetaFile records, inside each trash entry, where its files came from
+const trashMetaFile = "meta.json"
+
+// trashEntry is one deleted conversation: its session file and sub-agent
+// transcripts, kept in a directory of their own
+type trashEntry struct {
+	Dir       string      `json:"-"` // Trash entry directory
+	SessionID string      `json:"session_id"`
+	Cwd       string      `json:"cwd"`
+	Topic     string      `json:"topic"`
+	DeletedAt time.Time   `json:"deleted_at"`
+	Files     []trashFile `json:"files"`
+}
+
+// trashFile is a file in a trash entry and the path to restore it to
+type trashFile struct {
+	Name     string `json:"name"`
+	Original string `json:"original"`
+}
+
+// trashConversation moves a conversation's files into a new trash entry
+func trashConversation(conv Conversation) (trashEntry, error) {
+	now := time.Now()
+	entry := trashEntry{
+		Dir:       filepath.Join(getTrashDir(), now.Format("20060102-150405.000000000")+"-"+conv.SessionID),
+		SessionID: conv.SessionID,
+		Cwd:       conv.Cwd,
+		Topic:     truncate(getTopic(conv), 200),
+		DeletedAt: now,
+	}
+	if err := os.MkdirAll(entry.Dir, 0755); err != nil {
+		return entry, err
+	}
+
+	for i, path := range conversationPaths(conv) {
+		name := filepath.Base(path)
+		if err := moveFile(path, filepath.Join(entry.Dir, name)); err != nil {
+			if os.IsNotExist(err) && i > 0 {
+				continue // A sub-agent file already gone is no loss
+			}
+			// Put back what was already moved so nothing is half-deleted
+			restoreFiles(entry)
+			os.RemoveAll(entry.Dir)
+			return entry, err
+		}
+		entry.Files = append(entry.Files, trashFile{Name: name, Original: path})
+	}
+	return entry, writeTrashMeta(entry)
+}
+
+func writeTrashMeta(entry trashEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(entry.Dir, trashMetaFile), data, 0644)
+}
+
+// restoreTrash moves an entry's files back where they came from and removes
+// the entry. It refuses to overwrite a file that has since been recreated.
+func restoreTrash(entry trashEntry) error {
+	for _, f := range entry.Files {
+		if _, err := os.Stat(f.Original); err == nil {
+			return fmt.Errorf("%s already exists", f.Original)
+		}
+	}
+	if err := restoreFiles(entry); err != nil {
+		return err
+	}
+	return os.RemoveAll(entry.Dir)
+}
+
+func restoreFiles(entry trashEntry) error {
+	for _, f := range entry.Files {
+		if err := os.MkdirAll(filepath.Dir(f.Original), 0755); err != nil {
+			return err
+		}
+		if err := moveFile(filepath.Join(entry.Dir, f.Name), f.Original); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameFile is os.Rename, declared as a variable so tests can make it
+// fail as it does across devices
+var renameFile = os.Rename
+
+// moveFile renames src to dst, copying when they're on different devices
+func moveFile(src, dst string) error {
+	if err := renameFile(src, dst); err == nil || os.IsNotExist(err) {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// Keep the modification time, which the age filters go by
+	if err := os.Chtimes(dst, time.Now(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// listTrash returns the trash entries, most recently deleted first
+func listTrash() ([]trashEntry, error) {
+	dirs, err := os.ReadDir(getTrashDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []trashEntry
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(getTrashDir(), d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, trashMetaFile))
+		if err != nil {
+			continue // Not an entry, or one still being written
+		}
+		var entry trashEntry
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		entry.Dir = dir
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeletedAt.After(entries[j].DeletedAt) })
+	return entries, nil
+}
+
+// deletedItem is a conversation the TUI moved to the trash
+type deletedItem struct {
+	entry trashEntry
+	item  listItem
+}
+
+// undoDelete restores the most recently deleted conversations and puts
+// them back in the list
+func (m *model) undoDelete() {
+	if len(m.lastDeleted) == 0 {
+		m.errorMsg = "Nothing to undo"
+		return
+	}
+	var restored []listItem
+	var left []deletedItem
+	var failures []string
+	for _, d := range m.lastDeleted {
+		if err := restoreTrash(d.entry); err != nil {
+			left = append(left, d)
+			failures = append(failures, err.Error())
+			continue
+		}
+		restored = append(restored, d.item)
+	}
+	// Keep what couldn't be restored so a retry can pick it up
+	m.errorMsg = bulkFailures("Undo", len(m.lastDeleted), failures)
+	m.lastDeleted = left
+	if len(restored) == 0 {
+		return
+	}
+	// Items are kept newest first
+	for _, item := range restored {
+		i := sort.Search(len(m.items), func(i int) bool {
+			return m.items[i].conv.LastTimestamp <= item.conv.LastTimestamp
+		})
+		m.items = append(m.items[:i], append([]listItem{item}, m.items[i:]...)...)
+	}
+	m.updateFilter()
+	first := restored[0].conv.SessionID
+	for j, f := range m.filtered {
+		if f.conv.SessionID == first {
+			m.cursor = j
+			break
+		}
+	}
+	m.statusMsg = "Restored " + truncate(getTopic(restored[0].conv), 50)
+	if len(restored) > 1 {
+		m.statusMsg = fmt.Sprintf("Restored %d conversations", len(restored))
+	}
+}
+
+// parseAge parses an age such as 30d, 12h or 90m
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (want e.g. 30d or 12h)", s)
+	}
+	return d, nil
+}
+
+// runTrash implements `ccs trash list|restore|purge`
+func runTrash(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: ccs trash list | restore <session> | purge --older-than=AGE|--all")
+	}
+	entries, err := listTrash()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) > 1 {
+			return fmt.Errorf("unexpected argument %q", args[1])
+		}
+		if len(entries) == 0 {
+			fmt.Println("Trash is empty")
+			return nil
+		}
+		format := "%-16s  %-22s  %-36s  %s\n"
+		fmt.Printf(format, "DELETED", "PROJECT", "SESSION", "TOPIC")
+		for _, e := range entries {
+			fmt.Printf(format, e.DeletedAt.Local().Format("2006-01-02 15:04"), truncate(filepath.Base(e.Cwd), 22), e.SessionID, truncate(e.Topic, 50))
+		}
+		return nil
+
+	case "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: ccs trash restore <session-id or prefix>")
+		}
+		// The most recent deletion of the session wins
+		var match *trashEntry
+		for i, e := range entries {
+			if strings.HasPrefix(e.SessionID, args[1]) {
+				if match != nil && match.SessionID != e.SessionID {
+					return fmt.Errorf("session ID %q is ambiguous: %s, %s", args[1], match.SessionID, e.SessionID)
+				}
+				if match == nil {
+					match = &entries[i]
+				}
+			}
+		}
+		if match == nil {
+			return fmt.Errorf("no session in the trash matches %q", args[1])
+		}
+		if err := restoreTrash(*match); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s to %s\n", match.SessionID, match.Files[0].Original)
+		return nil
+
+	case "purge":
+		var maxAge time.Duration
+		all, aged := false, false
+		for _, arg := range args[1:] {
+			switch {
+			case arg == "--all":
+				all = true
+			case strings.HasPrefix(arg, "--older-than="):
+				if maxAge, err = parseAge(strings.TrimPrefix(arg, "--older-than=")); err != nil {
+					return err
+				}
+				aged = true
+			default:
+				return fmt.Errorf("unknown flag %s", arg)
+			}
+		}
+		if !all && !aged {
+			return fmt.Errorf("purge deletes for good; give --older-than=AGE (e.g. 30d) or --all")
+		}
+		purged := 0
+		for _, e := range entries {
+			if all || time.Since(e.DeletedAt) > maxAge {
+				if err := os.RemoveAll(e.Dir); err != nil {
+					return err
+				}
+				purged++
+			}
+		}
+		fmt.Printf("Purged %d of %d conversations from the trash\n", purged, len(entries))
+		return nil
+	}
+	return fmt.Errorf("unknown trash command %q (want list, restore or purge)", args[0])
+}
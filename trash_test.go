@@ -0,0 +1,206 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	conv := Conversation{
+		SessionID: "sess-1",
+		FilePath:  filepath.Join(dir, "sess-1.jsonl"),
+		Messages:  []Message{{Role: "user", Text: "fix the login bug"}},
+		Agents:    []Conversation{{FilePath: filepath.Join(dir, "agent-a1.jsonl")}},
+	}
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	os.WriteFile(conv.Agents[0].FilePath, []byte("agent\n"), 0644)
+
+	entry, err := trashConversation(conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{conv.FilePath, conv.Agents[0].FilePath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been moved to the trash", path)
+		}
+	}
+
+	entries, err := listTrash()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("listTrash = %+v, %v", entries, err)
+	}
+	if entries[0].SessionID != "sess-1" || entries[0].Topic != "fix the login bug" || len(entries[0].Files) != 2 {
+		t.Errorf("entry = %+v", entries[0])
+	}
+
+	if err := restoreTrash(entry); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(conv.FilePath); err != nil || string(data) != "session\n" {
+		t.Errorf("session file not restored: %q, %v", data, err)
+	}
+	if _, err := os.Stat(conv.Agents[0].FilePath); err != nil {
+		t.Error("sub-agent file not restored")
+	}
+	if entries, _ := listTrash(); len(entries) != 0 {
+		t.Error("a restored entry should leave the trash")
+	}
+}
+
+func TestRestoreTrashWontOverwrite(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	conv := Conversation{SessionID: "sess-1", FilePath: filepath.Join(dir, "sess-1.jsonl"), LastTimestamp: "2024-01-15T10:00:00Z"}
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	entry, _ := trashConversation(conv)
+	os.WriteFile(conv.FilePath, []byte("new session\n"), 0644)
+
+	if err := restoreTrash(entry); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("restore over a recreated file = %v", err)
+	}
+	if data, _ := os.ReadFile(conv.FilePath); string(data) != "new session\n" {
+		t.Error("the new file should be left alone")
+	}
+}
+
+func TestMoveFileAcrossDevices(t *testing.T) {
+	oldRenameFile := renameFile
+	renameFile = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { renameFile = oldRenameFile })
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "a.jsonl"), filepath.Join(dir, "b.jsonl")
+	os.WriteFile(src, []byte("session\n"), 0644)
+	mtime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	os.Chtimes(src, mtime, mtime)
+
+	if err := moveFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("the source should be removed after copying")
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("copied mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestDeleteAndUndo(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	conv := Conversation{SessionID: "sess-1", FilePath: filepath.Join(dir, "sess-1.jsonl"), LastTimestamp: "2024-01-15T10:00:00Z"}
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	other := Conversation{SessionID: "sess-0", LastTimestamp: "2024-01-16T10:00:00Z"}
+	m := initialModel([]listItem{{conv: other}, {conv: conv}}, "", nil)
+	m.cursor = 1
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	result, _ = result.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = result.(model)
+	if len(m.items) != 1 || !strings.Contains(m.statusMsg, "Ctrl+Z") {
+		t.Fatalf("after delete: %d items, status %q, error %q", len(m.items), m.statusMsg, m.errorMsg)
+	}
+	if _, err := os.Stat(conv.FilePath); !os.IsNotExist(err) {
+		t.Error("the session file should be in the trash")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	m = result.(model)
+	if len(m.items) != 2 || m.items[1].conv.SessionID != "sess-1" || m.cursor != 1 {
+		t.Errorf("undo should put the conversation back in place, got %d items, cursor %d", len(m.items), m.cursor)
+	}
+	if _, err := os.Stat(conv.FilePath); err != nil {
+		t.Error("undo should restore the session file")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if result.(model).errorMsg != "Nothing to undo" {
+		t.Error("a second undo has nothing to restore")
+	}
+}
+
+func TestPermanentDelete(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	conv := Conversation{SessionID: "sess-1", FilePath: filepath.Join(dir, "sess-1.jsonl"), LastTimestamp: "2024-01-15T10:00:00Z"}
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	m := initialModel([]listItem{{conv: conv}}, "", nil)
+	m.permanent = true
+	m.confirmDelete = true
+	m.deleteConversation()
+
+	if _, err := os.Stat(conv.FilePath); !os.IsNotExist(err) {
+		t.Error("the session file should be deleted")
+	}
+	if entries, _ := listTrash(); len(entries) != 0 || m.lastDeleted != nil {
+		t.Error("a permanent delete should bypass the trash")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"0d", 0, false},
+		{"d", 0, true},
+		{"-1d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestRunTrashPurge(t *testing.T) {
+	dir := filepath.Join(setupIndexTest(t), "-src-api")
+	os.MkdirAll(dir, 0755)
+	conv := Conversation{SessionID: "sess-1", FilePath: filepath.Join(dir, "sess-1.jsonl"), LastTimestamp: "2024-01-15T10:00:00Z"}
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	trashConversation(conv)
+
+	if err := runTrash([]string{"purge"}); err == nil {
+		t.Error("purge without --older-than or --all should refuse")
+	}
+	if err := runTrash([]string{"purge", "--older-than=1d"}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := listTrash(); len(entries) != 1 {
+		t.Error("a fresh deletion isn't older than a day")
+	}
+	if err := runTrash([]string{"purge", "--older-than=0d"}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := listTrash(); len(entries) != 0 {
+		t.Error("--older-than=0d should purge everything deleted so far")
+	}
+
+	os.WriteFile(conv.FilePath, []byte("session\n"), 0644)
+	trashConversation(conv)
+	if err := runTrash([]string{"purge", "--all"}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := listTrash(); len(entries) != 0 {
+		t.Error("--all should empty the trash")
+	}
+}